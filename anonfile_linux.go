@@ -0,0 +1,65 @@
+package wayland
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// memfdCreateTrap is the number of the memfd_create system call, which
+// the syscall package lacks for most architectures.
+var memfdCreateTrap = map[string]uintptr{
+	"386": 356, "amd64": 319, "arm": 385, "arm64": 279, "loong64": 279,
+	"ppc64": 360, "ppc64le": 360, "riscv64": 279, "s390x": 350,
+}
+
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	fAddSeals       = 1033
+	fSealShrink     = 0x2
+)
+
+func createMemfd(name string) (*os.File, error) {
+	trap, ok := memfdCreateTrap[runtime.GOARCH]
+	if !ok {
+		return nil, syscall.ENOSYS
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(p)), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	return os.NewFile(fd, name), nil
+}
+
+// allocate sets the size of the file like posix_fallocate, falling back to
+// ftruncate for file systems without fallocate support.
+func allocate(f *os.File, size int) error {
+	for {
+		err := syscall.Fallocate(int(f.Fd()), 0, 0, int64(size))
+		switch err {
+		case nil:
+			return nil
+		case syscall.EINTR:
+			continue
+		case syscall.EOPNOTSUPP, syscall.EINVAL, syscall.ENOSYS:
+			return syscall.Ftruncate(int(f.Fd()), int64(size))
+		}
+		return fmt.Errorf("Unable to allocate %d bytes for anonymous file: %w", size, err)
+	}
+}
+
+// seal keeps the file from shrinking. Files without sealing support, like
+// the temporary file fallback, are left alone.
+func seal(f *os.File) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), fAddSeals, fSealShrink); errno != 0 && errno != syscall.EINVAL {
+		return errno
+	}
+	return nil
+}
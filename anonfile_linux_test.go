@@ -0,0 +1,27 @@
+package wayland
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestCreateAnonymousFile(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	f, err := CreateAnonymousFile(4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var st syscall.Stat_t
+	syscall.Fstat(int(f.Fd()), &st)
+	if st.Size != 4096 || st.Nlink != 0 {
+		t.Errorf("Unexpected file of size %d with %d links", st.Size, st.Nlink)
+	}
+	if err := syscall.Ftruncate(int(f.Fd()), 8192); err != nil {
+		t.Errorf("Growing the file failed: %s", err)
+	}
+	// a compositor mapping the file relies on it not to shrink
+	if err := syscall.Ftruncate(int(f.Fd()), 1024); err != syscall.EPERM {
+		t.Errorf("Unexpected result of shrinking the file: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package wayland
+
+import (
+	"os"
+	"syscall"
+)
+
+// createMemfd reports that memfds are not available, so that the
+// temporary file fallback is used.
+func createMemfd(name string) (*os.File, error) {
+	return nil, syscall.ENOSYS
+}
+
+// allocate sets the size of the file with ftruncate.
+func allocate(f *os.File, size int) error {
+	return syscall.Ftruncate(int(f.Fd()), int64(size))
+}
+
+func seal(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package wayland
+
+import "encoding/binary"
+
+// ArrayOf interprets the contents of an array argument as 32 bit integers,
+// like the pressed keys of wl_keyboard.enter:
+//
+//	keys := wayland.ArrayOf[uint32](ev.Keys)
+//
+// Trailing bytes not making up a whole element are ignored.
+func ArrayOf[T ~int32 | ~uint32](a []byte) []T {
+	ret := make([]T, len(a)/4)
+	for i := range ret {
+		ret[i] = T(binary.LittleEndian.Uint32(a[i*4:]))
+	}
+	return ret
+}
+
+// ArrayFrom is the inverse of ArrayOf, it encodes integers as contents of
+// an array argument.
+func ArrayFrom[T ~int32 | ~uint32](values []T) []byte {
+	ret := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(ret[i*4:], uint32(v))
+	}
+	return ret
+}
+
+// KeyCodes returns the keys pressed when the surface got the keyboard
+// focus, as key codes like those of KeyboardKeyEvent.
+func (e KeyboardEnterEvent) KeyCodes() []uint32 {
+	return ArrayOf[uint32](e.Keys)
+}
@@ -0,0 +1,57 @@
+package wayland
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestArrayEncoding(t *testing.T) {
+	for _, a := range [][]byte{{}, {1}, {1, 2, 3, 4}, {1, 2, 3, 4, 5, 6}} {
+		m := newTestMessage()
+		m.Write(a)
+		m.Write(uint32(42))
+		if l := m.data.Len(); l%4 != 0 {
+			t.Errorf("Array of %d bytes not padded: %d bytes", len(a), l)
+		}
+		got, err := m.GetArray()
+		if err != nil || !bytes.Equal(got, a) {
+			t.Errorf("Array %v decoded as %v, %v", a, got, err)
+		}
+		if v, err := m.GetUint32(); v != 42 || err != nil {
+			t.Errorf("Argument after array of %d bytes decoded as %d, %v", len(a), v, err)
+		}
+	}
+	// the padding is part of the message as well
+	if _, err := newTestMessage(5, 0).GetArray(); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("Array without padding accepted: %v", err)
+	}
+}
+
+func TestArrayOf(t *testing.T) {
+	codes := []uint32{30, 31, 0xffffffff}
+	if got := ArrayOf[uint32](ArrayFrom(codes)); !reflect.DeepEqual(got, codes) {
+		t.Errorf("Array decoded as %v, expected %v", got, codes)
+	}
+	transforms := []OutputTransform{OutputTransform90, OutputTransformFlipped}
+	if got := ArrayOf[OutputTransform](ArrayFrom(transforms)); !reflect.DeepEqual(got, transforms) {
+		t.Errorf("Array decoded as %v, expected %v", got, transforms)
+	}
+	if got := ArrayOf[int32]([]byte{1, 0, 0, 0, 2}); !reflect.DeepEqual(got, []int32{1}) {
+		t.Errorf("Incomplete element decoded: %v", got)
+	}
+}
+
+func TestKeyboardEnterKeyCodes(t *testing.T) {
+	ctx := newConnection(nil)
+	keyboard, surface := NewKeyboard(ctx), NewSurface(ctx)
+	ctx.register(keyboard)
+	ctx.register(surface)
+	msg := newEvent(keyboard.Id(), 1, uint32(5), Proxy(surface), ArrayFrom([]uint32{30, 31}))
+	go keyboard.Dispatch(msg.Opcode, msg)
+	ev := <-keyboard.EnterChan
+	if got := ev.KeyCodes(); !reflect.DeepEqual(got, []uint32{30, 31}) {
+		t.Errorf("Unexpected pressed keys %v", got)
+	}
+}
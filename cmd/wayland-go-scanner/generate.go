@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// corePkg is the name the core package is imported as, the last element
+// of its import path need not be a valid identifier.
+const corePkg = "wayland"
+
+// generator emits Go code for the interfaces of one protocol. Interfaces
+// not defined by the protocol are looked up in the core package.
+type generator struct {
+	buf    bytes.Buffer
+	proto  *Protocol
+	pkg    string
+	prefix string
+	core   string // import path of the core package, empty for core itself
+	local  map[string]bool
+	signed map[string]bool // enums used by int arguments
+}
+
+func generate(proto *Protocol, source, pkg, prefix, core string) ([]byte, error) {
+	g := &generator{proto: proto, pkg: pkg, prefix: prefix, core: core,
+		local: make(map[string]bool), signed: make(map[string]bool)}
+	var imports []string
+	if core != "" {
+		imports = append(imports, core)
+	}
+	for _, ifc := range proto.Interfaces {
+		g.local[ifc.Name] = true
+		for _, msg := range append(ifc.Requests, ifc.Events...) {
+			for _, arg := range msg.Args {
+				if _, ok := signatureCodes[arg.Type]; !ok {
+					return nil, fmt.Errorf("Argument %s of %s.%s has unknown type %q.", arg.Name, ifc.Name, msg.Name, arg.Type)
+				}
+				if arg.Enum != "" && arg.Type == "int" {
+					i, e := enumRef(ifc.Name, arg.Enum)
+					g.signed[i+"."+e] = true
+				}
+			}
+		}
+		if len(ifc.Enums) > 0 && (len(imports) == 0 || imports[len(imports)-1] != "strconv") {
+			imports = append(imports, "strconv")
+		}
+	}
+	sort.Strings(imports)
+	g.printf("// Code generated by wayland-go-scanner from %s. DO NOT EDIT.\n\n", source)
+	g.printf("package %s\n\n", pkg)
+	switch len(imports) {
+	case 0:
+	case 1:
+		g.printf("import %s\n\n", g.importSpec(imports[0]))
+	default:
+		g.printf("import (\n")
+		for _, imp := range imports {
+			g.printf("\t%s\n", g.importSpec(imp))
+		}
+		g.printf(")\n\n")
+	}
+	for _, ifc := range proto.Interfaces {
+		for _, enum := range ifc.Enums {
+			g.enum(ifc, enum)
+		}
+	}
+	for _, ifc := range proto.Interfaces {
+		g.iface(ifc)
+	}
+	out, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return g.buf.Bytes(), fmt.Errorf("Generated code does not parse: %w", err)
+	}
+	return out, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) importSpec(imp string) string {
+	if imp == g.core {
+		return fmt.Sprintf("%s %q", corePkg, imp)
+	}
+	return strconv.Quote(imp)
+}
+
+// q qualifies an identifier of the core package.
+func (g *generator) q(name string) string {
+	if g.core == "" {
+		return name
+	}
+	return corePkg + "." + name
+}
+
+// typeName returns the Go type of the proxy for a protocol interface.
+func (g *generator) typeName(ifc string) string {
+	if g.local[ifc] {
+		return camelCase(strings.TrimPrefix(ifc, g.prefix))
+	}
+	return g.q(camelCase(strings.TrimPrefix(ifc, "wl_")))
+}
+
+// constructor returns the New* function of the proxy for an interface.
+func (g *generator) constructor(ifc string) string {
+	if g.local[ifc] {
+		return "New" + g.typeName(ifc)
+	}
+	return g.q("New" + camelCase(strings.TrimPrefix(ifc, "wl_")))
+}
+
+func (g *generator) varName(ifc Interface) string {
+	name := g.typeName(ifc.Name)
+	return strings.ToLower(name[:1]) + name[1:] + "Interface"
+}
+
+// enumRef resolves the enum attribute of an argument of interface ifc to
+// the interface and name of the enum.
+func enumRef(ifc, ref string) (string, string) {
+	if i := strings.IndexByte(ref, '.'); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ifc, ref
+}
+
+func (g *generator) enumType(ifc, enum string) string {
+	return g.typeName(ifc) + camelCase(enum)
+}
+
+func fieldName(arg Arg) string {
+	if arg.Name == "interface" {
+		return "Ifc"
+	}
+	return camelCase(arg.Name)
+}
+
+func paramName(arg Arg) string {
+	if arg.Name == "interface" {
+		return "ifc"
+	}
+	name := lowerCamelCase(arg.Name)
+	if token.IsKeyword(name) {
+		name += "_"
+	}
+	return name
+}
+
+// goType returns the Go type of an argument of a request or event of the
+// interface ifc.
+func (g *generator) goType(ifc string, arg Arg) string {
+	if arg.Enum != "" {
+		return g.enumType(enumRef(ifc, arg.Enum))
+	}
+	switch arg.Type {
+	case "int":
+		return "int32"
+	case "uint":
+		return "uint32"
+	case "fixed":
+		return g.q("Fixed")
+	case "string":
+		return "string"
+	case "array":
+		return "[]byte"
+	case "fd":
+		return "uintptr"
+	case "object", "new_id":
+		if arg.Interface == "" {
+			return g.q("Proxy")
+		}
+		return "*" + g.typeName(arg.Interface)
+	}
+	// generate rejects unknown types up front
+	panic("unknown argument type " + arg.Type)
+}
+
+var signatureCodes = map[string]byte{
+	"int": 'i', "uint": 'u', "fixed": 'f', "string": 's',
+	"object": 'o', "new_id": 'n', "array": 'a', "fd": 'h',
+}
+
+var getters = map[string]string{
+	"int": "GetInt32", "uint": "GetUint32", "fixed": "GetFixed",
+	"string": "GetString", "array": "GetArray", "fd": "GetFD",
+}
+
+func (g *generator) enum(ifc Interface, enum Enum) {
+	typ := g.enumType(ifc.Name, enum.Name)
+	underlying := "uint32"
+	if g.signed[ifc.Name+"."+enum.Name] {
+		underlying = "int32"
+	}
+	g.printf("type %s %s\n\nconst (\n", typ, underlying)
+	// entries with unique values, the first name wins
+	var consts, names []string
+	var values []uint64
+	seen := make(map[uint64]bool)
+	for _, entry := range enum.Entries {
+		c := typ + camelCase(entry.Name)
+		g.printf("\t%s %s = %s\n", c, typ, entry.Value)
+		v, err := strconv.ParseUint(entry.Value, 0, 32)
+		if err != nil || seen[v] {
+			continue
+		}
+		seen[v] = true
+		consts, names, values = append(consts, c), append(names, entry.Name), append(values, v)
+	}
+	g.printf(")\n\n")
+	if enum.Bitfield {
+		g.bitfield(typ, consts, names, values)
+		return
+	}
+
+	g.printf("func (e %s) String() string {\n\tswitch e {\n", typ)
+	for i, c := range consts {
+		g.printf("\tcase %s:\n\t\treturn %q\n", c, names[i])
+	}
+	g.printf("\t}\n\treturn \"%s(\" + strconv.Itoa(int(e)) + \")\"\n}\n\n", typ)
+	g.printf("func (e %s) IsValid() bool {\n\tswitch e {\n\tcase %s:\n\t\treturn true\n\t}\n\treturn false\n}\n\n",
+		typ, strings.Join(consts, ",\n\t\t"))
+}
+
+// bitfield emits the methods of a bitfield enum. String lists the names of
+// the single bit entries set.
+func (g *generator) bitfield(typ string, consts, names []string, values []uint64) {
+	var flags []string
+	zero := "0"
+	g.printf("func (e %s) String() string {\n\ts := \"\"\n", typ)
+	for i, c := range consts {
+		switch v := values[i]; {
+		case v == 0:
+			zero = names[i]
+		case v&(v-1) == 0:
+			flags = append(flags, c)
+			g.printf("\tif e&%s != 0 {\n\t\ts += \"|%s\"\n\t}\n", c, names[i])
+		}
+	}
+	mask := "0"
+	if len(flags) > 0 {
+		mask = "(" + strings.Join(flags, " | ") + ")"
+	}
+	g.printf("\tif rest := e &^ %s; rest != 0 {\n\t\ts += \"|0x\" + strconv.FormatUint(uint64(rest), 16)\n\t}\n", mask)
+	g.printf("\tif s == \"\" {\n\t\treturn %q\n\t}\n\treturn s[1:]\n}\n\n", zero)
+	g.printf("func (e %s) Has(flags %s) bool {\n\treturn e&flags == flags\n}\n\n", typ, typ)
+	g.printf("func (e %s) IsValid() bool {\n\treturn e&^%s == 0\n}\n\n", typ, mask)
+}
+
+func (g *generator) iface(ifc Interface) {
+	name := g.typeName(ifc.Name)
+	for _, ev := range ifc.Events {
+		g.printf("type %s%sEvent struct {\n", name, camelCase(ev.Name))
+		for _, arg := range ev.Args {
+			g.printf("\t%s %s\n", fieldName(arg), g.goType(ifc.Name, arg))
+		}
+		g.printf("}\n\n")
+	}
+
+	g.printf("type %s struct {\n\t%s\n", name, g.q("BaseProxy"))
+	for _, ev := range ifc.Events {
+		g.printf("\t%sChan chan %s%sEvent\n", camelCase(ev.Name), name, camelCase(ev.Name))
+	}
+	g.printf("}\n\n")
+
+	g.printf("func New%s(c *%s) *%s {\n\tret := &%s{}\n", name, g.q("Connection"), name, name)
+	for _, ev := range ifc.Events {
+		g.printf("\tret.%sChan = make(chan %s%sEvent, 0)\n", camelCase(ev.Name), name, camelCase(ev.Name))
+	}
+	g.printf("\tc.Register(ret)\n\treturn ret\n}\n\n")
+
+	g.descriptor(ifc)
+	g.printf("func (p *%s) Interface() *%s {\n\treturn &%s\n}\n\n", name, g.q("Interface"), g.varName(ifc))
+	g.dispatch(ifc)
+	for i, req := range ifc.Requests {
+		g.request(ifc, i, req)
+	}
+}
+
+func (g *generator) descriptor(ifc Interface) {
+	g.printf("var %s = %s{\n", g.varName(ifc), g.q("Interface"))
+	g.printf("\tName: %q,\n\tVersion: %s,\n", ifc.Name, ifc.Version)
+	g.methods("Requests", ifc.Requests)
+	g.methods("Events", ifc.Events)
+	for _, enum := range ifc.Enums {
+		if enum.Name != "error" {
+			continue
+		}
+		g.printf("\tErrors: map[uint32]string{\n")
+		for _, entry := range enum.Entries {
+			c := g.typeName(ifc.Name) + "Error" + camelCase(entry.Name)
+			g.printf("\t\tuint32(%s): %q,\n", c, c)
+		}
+		g.printf("\t},\n")
+	}
+	g.printf("}\n\n")
+}
+
+func (g *generator) methods(field string, msgs []Message) {
+	if len(msgs) == 0 {
+		return
+	}
+	g.printf("\t%s: []%s{\n", field, g.q("Method"))
+	for _, msg := range msgs {
+		var args []string
+		for _, arg := range msg.Args {
+			if arg.Type == "new_id" && arg.Interface == "" {
+				// untyped new_id, sent as interface name, version and id
+				args = append(args, `{Name: "interface", Type: 's'}`, `{Name: "version", Type: 'u'}`)
+			}
+			a := fmt.Sprintf("{Name: %q, Type: '%c'", arg.Name, signatureCodes[arg.Type])
+			if arg.Interface != "" {
+				a += fmt.Sprintf(", Interface: %q", arg.Interface)
+			}
+			if arg.AllowNull {
+				a += ", Nullable: true"
+			}
+			args = append(args, a+"}")
+		}
+		g.printf("\t\t{Name: %q", msg.Name)
+		if msg.Since != "" {
+			g.printf(", Since: %s", msg.Since)
+		}
+		if msg.Type == "destructor" {
+			g.printf(", Destructor: true")
+		}
+		if len(args) > 0 {
+			g.printf(", Args: []%s{%s}", g.q("Arg"), strings.Join(args, ", "))
+		}
+		g.printf("},\n")
+	}
+	g.printf("\t},\n")
+}
+
+func (g *generator) dispatch(ifc Interface) {
+	name := g.typeName(ifc.Name)
+	message := "*" + g.q("Message")
+	if len(ifc.Events) == 0 {
+		g.printf("func (p *%s) Dispatch(opcode uint32, m %s) error {\n\treturn nil\n}\n\n", name, message)
+		return
+	}
+	result := "error"
+	for _, ev := range ifc.Events {
+		if len(ev.Args) > 0 {
+			result = "(err error)"
+		}
+	}
+	g.printf("func (p *%s) Dispatch(opcode uint32, m %s) %s {\n\tswitch opcode {\n", name, message, result)
+	for i, ev := range ifc.Events {
+		g.printf("\tcase %d:\n\t\tvar ev %s%sEvent\n", i, name, camelCase(ev.Name))
+		for _, arg := range ev.Args {
+			field := "ev." + fieldName(arg)
+			switch {
+			case arg.Type == "new_id":
+				g.printf("\t\t%s = %s(p.Connection())\n", field, g.constructor(arg.Interface))
+				g.printf("\t\tif err = m.GetNewId(p, %s); err != nil {\n", field)
+			case arg.Type == "object" && arg.Interface != "":
+				g.printf("\t\tif %s, err = %s[%s](p.Connection(), m); err != nil {\n", field, g.q("GetObject"), g.goType(ifc.Name, arg))
+			case arg.Type == "object":
+				g.printf("\t\tif %s, err = m.GetProxy(p.Connection()); err != nil {\n", field)
+			case arg.Enum != "":
+				g.printf("\t\tif %s, err = %s[%s](m); err != nil {\n", field, g.q("GetEnum"), g.goType(ifc.Name, arg))
+			default:
+				g.printf("\t\tif %s, err = m.%s(); err != nil {\n", field, getters[arg.Type])
+			}
+			g.printf("\t\t\treturn err\n\t\t}\n")
+		}
+		ch := camelCase(ev.Name) + "Chan"
+		g.printf("\t\treturn %s(p, p.%s, \"%s.%s\", ev)\n", g.q("Deliver"), ch, name, ch)
+	}
+	g.printf("\t}\n\treturn nil\n}\n\n")
+}
+
+func (g *generator) request(ifc Interface, opcode int, req Message) {
+	var (
+		params []string
+		args   []string
+		ret    *Arg
+	)
+	for i, arg := range req.Args {
+		switch {
+		case arg.Type == "new_id" && arg.Interface != "":
+			ret = &req.Args[i]
+			args = append(args, g.q("Proxy")+"(ret)")
+			continue
+		case arg.Type == "new_id":
+			params = append(params, "ifc string", "version uint32")
+			args = append(args, "ifc", "version")
+		}
+		params = append(params, paramName(arg)+" "+g.goType(ifc.Name, arg))
+		switch {
+		case arg.Enum != "":
+			args = append(args, fmt.Sprintf("%s(%s)", g.goType(ifc.Name, Arg{Type: arg.Type}), paramName(arg)))
+		case arg.Type == "object" && arg.Interface != "":
+			// nil pointers are passed on as null objects
+			args = append(args, fmt.Sprintf("%s(%s)", g.q("ObjectArg"), paramName(arg)))
+		default:
+			args = append(args, paramName(arg))
+		}
+	}
+	send := fmt.Sprintf("p.Connection().SendRequest(%s)", strings.Join(append([]string{"p", fmt.Sprint(opcode)}, args...), ", "))
+	g.printf("func (p *%s) %s(%s) ", g.typeName(ifc.Name), camelCase(req.Name), strings.Join(params, ", "))
+	if ret != nil {
+		g.printf("(%s, error) {\n", g.goType(ifc.Name, *ret))
+		g.printf("\tret := %s(p.Connection())\n", g.constructor(ret.Interface))
+		g.printf("\treturn ret, %s\n}\n\n", send)
+	} else {
+		g.printf("error {\n\treturn %s\n}\n\n", send)
+	}
+}
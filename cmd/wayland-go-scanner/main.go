@@ -0,0 +1,59 @@
+// Command wayland-go-scanner generates Go proxies from Wayland protocol
+// XML files, the core protocol as well as extensions like those from
+// wayland-protocols.
+//
+// Usage:
+//
+//	wayland-go-scanner [flags] protocol.xml
+//
+// Extension protocols refer to core types like wl_surface through the
+// package given with -core.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	pkg := flag.String("pkg", "wayland", "package name of the generated code")
+	out := flag.String("o", "", "output file, standard output if empty")
+	prefix := flag.String("prefix", "wl_", "prefix stripped from interface names")
+	core := flag.String("core", "", "import path of the core wayland package when generating an extension protocol")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] protocol.xml\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *out, *pkg, *prefix, *core); err != nil {
+		fmt.Fprintf(os.Stderr, "wayland-go-scanner: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg, prefix, core string) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	proto, err := parseProtocol(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	code, err := generate(proto, filepath.Base(in), pkg, prefix, core)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0644)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func generateFile(t *testing.T, in, pkg, prefix, core string) []byte {
+	f, err := os.Open(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	proto, err := parseProtocol(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := generate(proto, filepath.Base(in), pkg, prefix, core)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// TestCoreProtocol checks that the committed core proxies are up to date,
+// run go generate in the repository root otherwise.
+func TestCoreProtocol(t *testing.T) {
+	code := generateFile(t, "../../protocol/wayland.xml", "wayland", "wl_", "")
+	want, err := os.ReadFile("../../wayland_client.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, want) {
+		t.Error("wayland_client.go differs from the generated code, run go generate")
+	}
+}
+
+func TestExtensionProtocol(t *testing.T) {
+	// the last element of the core path is no identifier
+	code := generateFile(t, "testdata/extension.xml", "picker", "ext_", "example.com/wayland-client")
+	golden := "testdata/extension.golden"
+	if *update {
+		if err := os.WriteFile(golden, code, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, want) {
+		t.Errorf("Generated code differs from %s, run go test -update after checking the changes", golden)
+	}
+}
+
+func TestUnknownArgumentType(t *testing.T) {
+	proto, err := parseProtocol(strings.NewReader(`<protocol name="bad">
+  <interface name="bad_thing" version="1">
+    <request name="set"><arg name="value" type="double"/></request>
+  </interface>
+</protocol>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generate(proto, "bad.xml", "bad", "bad_", "example.com/wayland"); err == nil {
+		t.Error("Argument of unknown type accepted")
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name, camel, param string
+	}{
+		{"set_buffer_scale", "SetBufferScale", "setBufferScale"},
+		{"class_", "Class", "class"},
+		{"flipped_90", "Flipped90", "flipped90"},
+		{"type", "Type", "type_"},
+		{"interface", "Interface", "ifc"},
+	}
+	for _, test := range tests {
+		if got := camelCase(test.name); got != test.camel {
+			t.Errorf("camelCase(%q) = %q, expected %q", test.name, got, test.camel)
+		}
+		if got := paramName(Arg{Name: test.name}); got != test.param {
+			t.Errorf("paramName(%q) = %q, expected %q", test.name, got, test.param)
+		}
+	}
+}
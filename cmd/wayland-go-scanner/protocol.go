@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+type Protocol struct {
+	Name       string      `xml:"name,attr"`
+	Interfaces []Interface `xml:"interface"`
+}
+
+type Interface struct {
+	Name     string    `xml:"name,attr"`
+	Version  string    `xml:"version,attr"`
+	Requests []Message `xml:"request"`
+	Events   []Message `xml:"event"`
+	Enums    []Enum    `xml:"enum"`
+}
+
+type Message struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Since string `xml:"since,attr"`
+	Args  []Arg  `xml:"arg"`
+}
+
+type Arg struct {
+	Name      string `xml:"name,attr"`
+	Type      string `xml:"type,attr"`
+	Interface string `xml:"interface,attr"`
+	Enum      string `xml:"enum,attr"`
+	AllowNull bool   `xml:"allow-null,attr"`
+}
+
+type Enum struct {
+	Name     string  `xml:"name,attr"`
+	Bitfield bool    `xml:"bitfield,attr"`
+	Entries  []Entry `xml:"entry"`
+}
+
+type Entry struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func parseProtocol(r io.Reader) (*Protocol, error) {
+	var p Protocol
+	if err := xml.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// camelCase converts a protocol name like set_buffer_scale to
+// SetBufferScale. Trailing underscores escaping keywords are dropped.
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// lowerCamelCase converts a protocol name to a Go parameter name.
+func lowerCamelCase(name string) string {
+	s := camelCase(name)
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -1,17 +1,81 @@
 package wayland
 
+//go:generate go run ./cmd/wayland-go-scanner -o wayland_client.go protocol/wayland.xml
+
 type ProxyId uint32
 
+// Ids from this value upwards are allocated by the server.
+const serverIdStart ProxyId = 0xff000000
+
+// Arg describes an argument of a request or event. Type is the libwayland
+// signature character: i, u, f, s, o, n, a or h. Interface is set for
+// object and new_id arguments of a fixed interface. Nullable object and
+// string arguments may be null, which is nil or the empty string in Go.
+type Arg struct {
+	Name      string
+	Type      byte
+	Interface string
+	Nullable  bool
+}
+
+// Method describes a request or event. Its opcode is its index in the
+// Requests or Events of the interface. Since is the interface version
+// which introduced it, zero for the first version. Destructor requests
+// destroy the object they are sent to.
+type Method struct {
+	Name       string
+	Since      uint32
+	Destructor bool
+	Args       []Arg
+}
+
+// Signature returns the argument types in libwayland notation.
+func (m Method) Signature() string {
+	sig := make([]byte, len(m.Args))
+	for i, arg := range m.Args {
+		sig[i] = arg.Type
+	}
+	return string(sig)
+}
+
+// Interface describes the protocol interface implemented by a proxy.
+type Interface struct {
+	Name     string
+	Version  uint32
+	Requests []Method
+	Events   []Method
+	Errors   map[uint32]string
+}
+
+// RequestOpcode returns the opcode of the request with the given name.
+func (i *Interface) RequestOpcode(name string) (uint32, bool) {
+	for opcode, m := range i.Requests {
+		if m.Name == name {
+			return uint32(opcode), true
+		}
+	}
+	return 0, false
+}
+
 type Proxy interface {
+	Interface() *Interface
+	// Dispatch decodes the event with the given opcode and delivers it to
+	// the proxy's channel.
+	Dispatch(opcode uint32, m *Message) error
 	Connection() *Connection
 	SetConnection(c *Connection)
 	Id() ProxyId
 	SetId(id ProxyId)
+	// Version is the interface version of the object, chosen by
+	// Registry.Bind and inherited by objects created through it.
+	Version() uint32
+	SetVersion(v uint32)
 }
 
 type BaseProxy struct {
-	id   ProxyId
-	conn *Connection
+	id      ProxyId
+	version uint32
+	conn    *Connection
 }
 
 func (p *BaseProxy) Id() ProxyId {
@@ -22,6 +86,14 @@ func (p *BaseProxy) SetId(id ProxyId) {
 	p.id = id
 }
 
+func (p *BaseProxy) Version() uint32 {
+	return p.version
+}
+
+func (p *BaseProxy) SetVersion(v uint32) {
+	p.version = v
+}
+
 func (p *BaseProxy) Connection() *Connection {
 	return p.conn
 }
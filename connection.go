@@ -1,36 +1,185 @@
 package wayland
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"net"
 	"os"
-	"reflect"
 	"sync"
+	"syscall"
 	"time"
 )
 
+var ErrConnectionClosed = errors.New("Wayland connection closed.")
+
+// ErrProxyDestroyed is returned for requests on an object after a
+// destructor request like Destroy or Release was sent for it.
+var ErrProxyDestroyed = errors.New("Wayland object already destroyed.")
+
+// Connection is safe for concurrent use. The object table is guarded by
+// mu, which the dispatcher holds while looking up the target of an event.
+// Requests are encoded and queued under wmu, one message at a time, and
+// ids of new objects are allocated in the same step, so they reach the
+// server in the order the protocol demands. Events are decoded by a single
+// dispatcher goroutine fed by a reader goroutine; see QueuePolicy for how
+// they are handed to channels. Lock order is wmu before mu.
+//
+// Destroyed and unregistered objects stay in the object table as zombies
+// until the server confirms the destruction, so that events still in flight
+// for them can be recognized. Their file descriptors are closed and the
+// events discarded, like the events still queued for them.
 type Connection struct {
 	mu              sync.Mutex
 	conn            *net.UnixConn
 	currentId       ProxyId
+	freeIds         []ProxyId
 	objects         map[ProxyId]Proxy
+	zombies         map[Proxy]bool
+	manual          bool
 	dispatchRequest chan bool
-	exit            chan bool
+	messages        chan *Message
+	done            chan struct{}
+	err             error
+	shutdown        sync.Once
+	events          *eventQueues
+	fds             fdQueue
+	wmu             sync.Mutex
+	out             bytes.Buffer
+	outFds          []int
+	batching        bool
+	flushRequest    chan struct{}
+	writes          int
+}
+
+type Option func(*Connection)
+
+// WithManualDispatch restores the original dispatch mode in which every
+// event has to be requested by sending on Connection.Dispatch().
+func WithManualDispatch() Option {
+	return func(c *Connection) {
+		c.manual = true
+	}
 }
 
+// Register attaches a proxy to the connection. Its id is allocated once the
+// proxy is sent as new object with a request.
 func (context *Connection) Register(proxy Proxy) {
+	proxy.SetConnection(context)
+}
+
+// register allocates an id for the proxy and adds it to the object table.
+func (context *Connection) register(proxy Proxy) {
 	context.mu.Lock()
-	context.currentId += 1
-	proxy.SetId(context.currentId)
+	id := context.allocateId()
+	proxy.SetId(id)
 	proxy.SetConnection(context)
-	context.objects[context.currentId] = proxy
+	context.objects[id] = proxy
 	context.mu.Unlock()
 }
 
-func (context *Connection) Unregister(proxy Proxy) {
+// release undoes register for a proxy the server never learned about.
+func (context *Connection) release(proxy Proxy) {
 	context.mu.Lock()
 	delete(context.objects, proxy.Id())
+	context.freeIds = append(context.freeIds, proxy.Id())
+	proxy.SetId(0)
+	context.mu.Unlock()
+}
+
+// allocateId reuses ids released by wl_display.delete_id before handing
+// out new ones. Must be called with mu held.
+func (context *Connection) allocateId() ProxyId {
+	if n := len(context.freeIds); n > 0 {
+		id := context.freeIds[n-1]
+		context.freeIds = context.freeIds[:n-1]
+		return id
+	}
+	context.currentId += 1
+	return context.currentId
+}
+
+// deleteId retires the object once the server acknowledged its
+// destruction and makes its id available again.
+func (context *Connection) deleteId(id ProxyId) {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	proxy, ok := context.objects[id]
+	if !ok || id >= serverIdStart {
+		return
+	}
+	delete(context.objects, id)
+	context.freeIds = append(context.freeIds, id)
+	delete(context.zombies, proxy)
+	context.events.forget(proxy)
+}
+
+// destroy turns the proxy into a zombie after a destructor request.
+func (context *Connection) destroy(proxy Proxy) {
+	context.events.forget(proxy)
+	context.mu.Lock()
+	context.zombies[proxy] = true
+	context.mu.Unlock()
+}
+
+// discard consumes the file descriptors of an event for a zombie and
+// closes them. Objects announced by the event are registered as zombies,
+// so that their own events are recognized.
+func discard(proxy Proxy, msg *Message) error {
+	args := proxy.Interface().Events[msg.Opcode].Args
+	for _, arg := range args {
+		if arg.Type == 'n' {
+			// the proxy decodes the event, see Message.discard
+			msg.discard = true
+			return proxy.Dispatch(msg.Opcode, msg)
+		}
+	}
+	for _, arg := range args {
+		if arg.Type != 'h' {
+			continue
+		}
+		fd, err := msg.GetFD()
+		if err != nil {
+			return err
+		}
+		syscall.Close(int(fd))
+	}
+	return nil
+}
+
+// RegisterWithId registers a proxy for an object created by the server,
+// e.g. a wl_data_offer announced by wl_data_device.data_offer.
+func (context *Connection) RegisterWithId(proxy Proxy, id ProxyId) error {
+	if id < serverIdStart {
+		return fmt.Errorf("Object id %d is not in the server range.", id)
+	}
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	// the server does not confirm destruction of its objects with
+	// delete_id, so the ids of zombies can be taken over once it
+	// announces a new object with them
+	if old, ok := context.objects[id]; ok && !context.zombies[old] {
+		return fmt.Errorf("Object id %d already in use.", id)
+	} else if ok {
+		delete(context.zombies, old)
+	}
+	proxy.SetId(id)
+	proxy.SetConnection(context)
+	context.objects[id] = proxy
+	return nil
+}
+
+// Unregister stops event delivery to the proxy and turns it into a zombie,
+// so that file descriptors of its late events are closed. Client allocated
+// ids stay reserved until the server confirms them with
+// wl_display.delete_id, server allocated ones until the server reuses them
+// for a new object.
+func (context *Connection) Unregister(proxy Proxy) {
+	context.events.forget(proxy)
+	context.mu.Lock()
+	if context.objects[proxy.Id()] == proxy {
+		context.zombies[proxy] = true
+	}
 	context.mu.Unlock()
 }
 
@@ -38,16 +187,60 @@ func (context *Connection) Close() error {
 	if context.conn == nil {
 		return errors.New("Wayland connection not established.")
 	}
-	context.conn.Close()
-	context.exit <- true
+	context.fail(ErrConnectionClosed)
 	return nil
 }
 
+// fail terminates the connection with err, unless it already terminated,
+// and stops event delivery.
+func (context *Connection) fail(err error) {
+	context.terminate(err)
+	context.shutdown.Do(func() {
+		context.events.close()
+		context.fds.closeAll()
+	})
+}
+
+// terminate records err as reason of termination and closes the socket,
+// unless the connection already terminated.
+func (context *Connection) terminate(err error) {
+	context.mu.Lock()
+	if context.err != nil {
+		context.mu.Unlock()
+		return
+	}
+	context.err = err
+	close(context.done)
+	context.mu.Unlock()
+	context.conn.Close()
+}
+
+// Done returns a channel which is closed once the connection terminated,
+// either by Close or by a fatal error.
+func (context *Connection) Done() <-chan struct{} {
+	return context.done
+}
+
+// Err returns nil while the connection is alive. Afterwards it returns the
+// reason of termination, ErrConnectionClosed after Close.
+func (context *Connection) Err() error {
+	context.mu.Lock()
+	defer context.mu.Unlock()
+	return context.err
+}
+
+// Dispatch returns the channel to request dispatching of a single event
+// when the connection was created with WithManualDispatch. Otherwise events
+// are dispatched continuously and the returned channel is nil, so a send on
+// it in a select statement is never chosen.
 func (context *Connection) Dispatch() chan<- bool {
+	if !context.manual {
+		return nil
+	}
 	return context.dispatchRequest
 }
 
-func ConnectDisplay(addr string) (ret *Display, err error) {
+func ConnectDisplay(addr string, opts ...Option) (ret *Display, err error) {
 	runtime_dir := os.Getenv("XDG_RUNTIME_DIR")
 	if runtime_dir == "" {
 		return nil, errors.New("XDG_RUNTIME_DIR not set in the environment.")
@@ -59,85 +252,228 @@ func ConnectDisplay(addr string) (ret *Display, err error) {
 		addr = "wayland-0"
 	}
 	addr = runtime_dir + "/" + addr
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: addr, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	return startDisplay(newConnection(conn, opts...)), nil
+}
+
+// startDisplay creates the wl_display object and starts dispatching.
+// Display events are queued and never block the connection, so nobody
+// has to drain DeleteIdChan.
+func startDisplay(ctx *Connection) *Display {
+	ret := NewDisplay(ctx)
+	ret.SetVersion(1)
+	ctx.register(ret)
+	ctx.SetQueuePolicy(ret, QueueDropOldest, defaultQueueSize)
+	ctx.start()
+	return ret
+}
+
+func newConnection(conn *net.UnixConn, opts ...Option) *Connection {
 	ctx := &Connection{}
 	ctx.objects = make(map[ProxyId]Proxy)
+	ctx.zombies = make(map[Proxy]bool)
 	ctx.currentId = 0
 	ctx.dispatchRequest = make(chan bool)
-	ctx.exit = make(chan bool)
-	ctx.conn, err = net.DialUnix("unix", nil, &net.UnixAddr{addr, "unix"})
-	if err != nil {
-		return nil, err
+	ctx.messages = make(chan *Message)
+	ctx.done = make(chan struct{})
+	ctx.flushRequest = make(chan struct{}, 1)
+	ctx.events = newEventQueues()
+	ctx.conn = conn
+	for _, opt := range opts {
+		opt(ctx)
 	}
-	ret = NewDisplay(ctx)
-	// dispatch events in separate gorutine
-	go ctx.run()
-	return ret, nil
+	return ctx
 }
 
 func (context *Connection) SendRequest(proxy Proxy, opcode uint32, args ...interface{}) (err error) {
 	if context.conn == nil {
 		return errors.New("No wayland connection established for Proxy object.")
 	}
+	if err = context.Err(); err != nil {
+		return err
+	}
+	context.wmu.Lock()
+	defer context.wmu.Unlock()
+	if proxy.Id() == 0 {
+		return fmt.Errorf("Object %T was not created by a request yet.", proxy)
+	}
+	ifc := proxy.Interface()
+	if int(opcode) >= len(ifc.Requests) {
+		return fmt.Errorf("Invalid opcode %d for %s.", opcode, ifc.Name)
+	}
+	method := ifc.Requests[opcode]
+	if method.Since > proxy.Version() {
+		return &VersionError{ifc.Name, method.Name, method.Since, proxy.Version()}
+	}
+	context.mu.Lock()
+	zombie := context.zombies[proxy]
+	context.mu.Unlock()
+	if zombie {
+		return ErrProxyDestroyed
+	}
 	msg := NewRequest(proxy, opcode)
 
-	for _, arg := range args {
+	var created []Proxy
+	for i, arg := range args {
+		newId := i < len(method.Args) && method.Args[i].Type == 'n'
+		if p, ok := arg.(Proxy); ok && p.Id() == 0 && !newId {
+			err = fmt.Errorf("Argument %d of %s.%s, a %T, was not created by a request yet.", i, ifc.Name, method.Name, p)
+			break
+		}
+		if p, ok := arg.(Proxy); ok && p.Id() == 0 && newId && p.Connection() == context {
+			// new objects inherit the version, unless bound explicitly
+			version := proxy.Version()
+			if i > 0 && method.Args[i].Interface == "" {
+				version, _ = args[i-1].(uint32)
+				if supported := p.Interface().Version; version > supported {
+					err = fmt.Errorf("Version %d of %s not supported, maximum is %d.", version, p.Interface().Name, supported)
+					break
+				}
+			}
+			p.SetVersion(version)
+			context.register(p)
+			created = append(created, p)
+		}
+		if arg == nil || arg == "" {
+			// null objects and strings are sent as zero
+			nullable := i < len(method.Args) && method.Args[i].Nullable
+			if arg == nil && !nullable {
+				err = fmt.Errorf("Argument %d of %s.%s must not be null.", i, ifc.Name, method.Name)
+				break
+			}
+			if nullable {
+				arg = uint32(0)
+			}
+		}
 		if err = msg.Write(arg); err != nil {
-			return err
+			break
 		}
 	}
-
-	return SendWaylandMessage(context.conn, msg)
+	if err == nil {
+		err = context.queueRequestLocked(msg)
+	}
+	if err == nil && method.Destructor {
+		context.destroy(proxy)
+	}
+	if err != nil {
+		for _, p := range created {
+			context.release(p)
+		}
+		if cerr := context.Err(); cerr != nil {
+			return cerr
+		}
+	}
+	return err
 }
 
-func dispatchEvent(proxy Proxy, m *Message) {
-	v := reflect.ValueOf(proxy)
-	f := v.Elem().Field(int(m.Opcode) + 1) // +1 because of BaseProxy
-	t := f.Type().Elem()
-	ev := reflect.New(t)
-	el := ev.Elem()
-	for i := 0; i < el.NumField(); i++ {
-		ef := el.Field(i)
-		var fv reflect.Value
-		switch ef.Kind() {
-		case reflect.Int32:
-			fv = reflect.ValueOf(m.GetInt32())
-		case reflect.Uint32:
-			fv = reflect.ValueOf(m.GetUint32())
-		case reflect.Float32:
-			fv = reflect.ValueOf(m.GetFloat32())
-		case reflect.String:
-			fv = reflect.ValueOf(m.GetString())
-		case reflect.Slice:
-			fv = reflect.ValueOf(m.GetArray())
-		case reflect.Uintptr:
-			fv = reflect.ValueOf(m.GetFD())
-		case reflect.Ptr:
-			fv = reflect.ValueOf(m.GetProxy(proxy.Connection())).Elem().Addr()
-		default:
-			panic(fmt.Sprint("Not handled field type: ", ef.Kind().String()))
-		}
-		ef.Set(fv)
+// Deliver hands an event decoded by a proxy's Dispatch method over to the
+// channel ch according to the queue policy of the connection. Display
+// events additionally update the connection state.
+func Deliver[E any](p Proxy, ch chan E, name string, ev E) error {
+	c := p.Connection()
+	c.mu.Lock()
+	zombie := c.zombies[p]
+	c.mu.Unlock()
+	if zombie {
+		return nil
+	}
+	if e, ok := any(ev).(DisplayDeleteIdEvent); ok {
+		c.deleteId(ProxyId(e.Id))
+	}
+	if e, ok := any(ev).(DisplayErrorEvent); ok {
+		// the connection is dead after a protocol error. Waiters learn
+		// about it at once, the event is handed over before the queues
+		// shut down.
+		err := newProtocolError(e)
+		c.terminate(err)
+		c.events.deliverLast(chanSender(ch, ev))
+		return err
+	}
+	if !c.events.push(p, ch, name, chanSender(ch, ev), func() { closeFds(ev) }) {
+		return c.Err()
 	}
-	f.Send(el)
+	return nil
 }
 
-func (context *Connection) run() error {
+// start launches the reader and dispatcher gorutines.
+func (context *Connection) start() {
 	context.conn.SetReadDeadline(time.Time{})
+	go context.read()
+	go context.run()
+}
+
+// read decodes messages continuously and hands them to the dispatcher.
+func (context *Connection) read() {
+	reader := newMessageReader(context.conn, &context.fds)
 	for {
+		msg, err := reader.Next()
+		if err != nil {
+			context.fail(fmt.Errorf("Unable to read message: %w", err))
+			return
+		}
 		select {
-		case <-context.dispatchRequest:
-			msg, err := ReadWaylandMessage(context.conn)
-			if err != nil {
-				continue
+		case context.messages <- msg:
+		case <-context.done:
+			return
+		}
+	}
+}
+
+func (context *Connection) run() {
+	var idle <-chan time.Time
+	for {
+		if context.manual {
+			select {
+			case <-context.dispatchRequest:
+			case <-context.done:
+				return
+			}
+			if err := context.Flush(); err != nil {
+				context.fail(err)
+				return
 			}
-			proxy, ok := context.objects[msg.Id]
-			if !ok {
-				return errors.New(fmt.Sprintf("Unknown object id: %d", msg.Id))
+		}
+		var msg *Message
+		for msg == nil {
+			select {
+			case msg = <-context.messages:
+			case <-context.flushRequest:
+				idle = time.After(idleFlushDelay)
+			case <-idle:
+				idle = nil
+				if err := context.Flush(); err != nil {
+					context.fail(err)
+					return
+				}
+			case <-context.done:
+				return
 			}
-			dispatchEvent(proxy, msg)
-		case <-context.exit:
-			return nil
+		}
+		context.mu.Lock()
+		proxy, ok := context.objects[msg.Id]
+		zombie := context.zombies[proxy]
+		context.mu.Unlock()
+		if !ok {
+			context.fail(fmt.Errorf("Unknown object id: %d", msg.Id))
+			return
+		}
+		if int(msg.Opcode) >= len(proxy.Interface().Events) {
+			context.fail(corrupted("invalid opcode %d for %s@%d", msg.Opcode, proxy.Interface().Name, msg.Id))
+			return
+		}
+		if zombie {
+			if err := discard(proxy, msg); err != nil {
+				context.fail(err)
+				return
+			}
+			continue
+		}
+		if err := proxy.Dispatch(msg.Opcode, msg); err != nil {
+			context.fail(err)
+			return
 		}
 	}
-	return nil
 }
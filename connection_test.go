@@ -0,0 +1,564 @@
+package wayland
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// socketPair returns both ends of a connected unix stream socket.
+func socketPair(t testing.TB) (*net.UnixConn, *net.UnixConn) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("socketpair: %s", err)
+	}
+	var conns [2]*net.UnixConn
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		c, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("FileConn: %s", err)
+		}
+		conns[i] = c.(*net.UnixConn)
+	}
+	return conns[0], conns[1]
+}
+
+// newTestDisplay connects a Display to a fake server end of a socket pair.
+func newTestDisplay(t testing.TB, opts ...Option) (*Display, *net.UnixConn) {
+	client, server := socketPair(t)
+	display := startDisplay(newConnection(client, opts...))
+	t.Cleanup(func() { server.Close() })
+	return display, server
+}
+
+// sendEvent writes an event from the fake server side.
+func sendEvent(t testing.TB, conn *net.UnixConn, id ProxyId, opcode uint32, args ...interface{}) {
+	msg := &Message{Id: id, Opcode: opcode, data: &bytes.Buffer{}}
+	for _, arg := range args {
+		if err := msg.Write(arg); err != nil {
+			t.Fatalf("Unable to encode event argument: %s", err)
+		}
+	}
+	if err := SendWaylandMessage(conn, msg); err != nil {
+		t.Fatalf("Unable to send event: %s", err)
+	}
+}
+
+func TestServerCreatedObject(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	device := NewDataDevice(display.Connection())
+	device.SetVersion(3)
+	display.Connection().register(device)
+
+	offerId := serverIdStart
+	sendEvent(t, server, device.Id(), 0, uint32(offerId))
+	sendEvent(t, server, offerId, 0, "text/plain")
+
+	var offer *DataOffer
+	for offer == nil {
+		select {
+		case ev := <-device.DataOfferChan:
+			offer = ev.Id
+			if offer == nil || offer.Id() != offerId {
+				t.Fatalf("Unexpected data offer: %v", offer)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for data offer")
+		case display.Connection().Dispatch() <- true:
+		}
+	}
+loop:
+	for {
+		select {
+		case ev := <-offer.OfferChan:
+			if ev.MimeType != "text/plain" {
+				t.Errorf("Unexpected mime type %q", ev.MimeType)
+			}
+			break loop
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for events")
+		case display.Connection().Dispatch() <- true:
+		}
+	}
+	if display.Connection().objects[offerId] != Proxy(offer) {
+		t.Error("Data offer not registered under the server id")
+	}
+	if v := offer.Version(); v != 3 {
+		t.Errorf("Data offer has version %d, expected the device version 3", v)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	registry, err := display.GetRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := registry.Version(); v != 1 {
+		t.Errorf("Registry has version %d, expected 1", v)
+	}
+
+	compositor := NewCompositor(display.Connection())
+	if err := registry.Bind(1, "wl_compositor", 4, compositor); err != nil {
+		t.Fatal(err)
+	}
+	surface, err := compositor.CreateSurface()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := surface.Version(); v != 4 {
+		t.Errorf("Surface has version %d, expected 4", v)
+	}
+	if err := surface.DamageBuffer(0, 0, 10, 10); err != nil {
+		t.Errorf("Request of version 4 failed: %s", err)
+	}
+
+	writes := display.Connection().writes
+	var verr *VersionError
+	if err := surface.Offset(1, 1); !errors.As(err, &verr) {
+		t.Fatalf("Unexpected error for request of version 5: %v", err)
+	}
+	if verr.Request != "offset" || verr.Since != 5 || verr.Version != 4 {
+		t.Errorf("Unexpected version error %+v", verr)
+	}
+	if display.Connection().writes != writes {
+		t.Error("Unsupported request was sent")
+	}
+
+	shm := NewShm(display.Connection())
+	if err := registry.Bind(2, "wl_shm", shmInterface.Version+1, shm); err == nil {
+		t.Error("Bind accepted a version newer than the interface")
+	}
+	if shm.Id() != 0 {
+		t.Error("Failed bind allocated an id")
+	}
+}
+
+func TestUncreatedObjectArgument(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	ctx := display.Connection()
+	surface := NewSurface(ctx)
+	ctx.register(surface)
+	buffer := NewBuffer(ctx)
+	writes := ctx.writes
+	if err := surface.Attach(buffer, 0, 0); err == nil {
+		t.Error("Buffer attached before it was created")
+	}
+	if buffer.Id() != 0 {
+		t.Errorf("Object argument got id %d", buffer.Id())
+	}
+	if ctx.writes != writes {
+		t.Error("Request with uncreated object was sent")
+	}
+	if err := surface.Attach(nil, 0, 0); err != nil {
+		t.Errorf("Null buffer not attached: %v", err)
+	}
+}
+
+func TestRegisterWithId(t *testing.T) {
+	ctx := newConnection(nil)
+	if err := ctx.RegisterWithId(&DataOffer{}, 5); err == nil {
+		t.Error("Client id accepted as server object id")
+	}
+	if err := ctx.RegisterWithId(&DataOffer{}, serverIdStart); err != nil {
+		t.Error(err)
+	}
+	if err := ctx.RegisterWithId(&DataOffer{}, serverIdStart); err == nil {
+		t.Error("Duplicate server object id accepted")
+	}
+	ctx.Unregister(ctx.objects[serverIdStart])
+	if _, ok := ctx.objects[serverIdStart]; !ok {
+		t.Error("Unregistered server object id released before it was reused")
+	}
+	if err := ctx.RegisterWithId(&DataOffer{}, serverIdStart); err != nil {
+		t.Errorf("Id of unregistered server object not reusable: %s", err)
+	}
+}
+
+func TestUnregisteredServerObject(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	ctx := display.Connection()
+	offer := NewDataOffer(ctx)
+	if err := ctx.RegisterWithId(offer, serverIdStart); err != nil {
+		t.Fatal(err)
+	}
+	probe := NewCallback(ctx)
+	ctx.register(probe)
+	ctx.Unregister(offer)
+	// an offer event still in flight must not kill the connection
+	sendEvent(t, server, serverIdStart, 0, "text/plain")
+	sendEvent(t, server, probe.Id(), 0, uint32(0))
+	if _, err := probe.Wait(testContext(t)); err != nil {
+		t.Fatalf("Event for unregistered server object failed the connection: %s", err)
+	}
+}
+
+func TestZombieCreatesObject(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	ctx := display.Connection()
+	device := NewDataDevice(ctx)
+	device.SetVersion(3)
+	ctx.register(device)
+	probe := NewCallback(ctx)
+	ctx.register(probe)
+	if err := device.Release(); err != nil {
+		t.Fatal(err)
+	}
+	// the server announced an offer before it saw the release
+	sendEvent(t, server, device.Id(), 0, uint32(serverIdStart+1))
+	sendEvent(t, server, serverIdStart+1, 0, "text/plain")
+	sendEvent(t, server, probe.Id(), 0, uint32(0))
+	if _, err := probe.Wait(testContext(t)); err != nil {
+		t.Fatalf("Event for object created by a zombie failed the connection: %s", err)
+	}
+	offer := ctx.objects[serverIdStart+1]
+	if _, ok := offer.(*DataOffer); !ok || !ctx.zombies[offer] {
+		t.Errorf("Data offer %v not registered as zombie", offer)
+	}
+	select {
+	case ev := <-device.DataOfferChan:
+		t.Errorf("Event of zombie delivered: %v", ev)
+	default:
+	}
+}
+
+// serveSync answers every wl_display.sync with wl_callback.done followed
+// by wl_display.delete_id, the way a compositor does.
+func serveSync(server *net.UnixConn) {
+	var serial uint32
+	for {
+		msg, err := ReadWaylandMessage(server)
+		if err != nil {
+			return
+		}
+		if msg.Id != 1 || msg.Opcode != 0 {
+			continue
+		}
+		arg, _ := msg.GetUint32()
+		id := ProxyId(arg)
+		serial++
+		for _, ev := range []*Message{newEvent(id, 0, serial), newEvent(1, 1, uint32(id))} {
+			if SendWaylandMessage(server, ev) != nil {
+				return
+			}
+		}
+	}
+}
+
+func newEvent(id ProxyId, opcode uint32, args ...interface{}) *Message {
+	msg := &Message{Id: id, Opcode: opcode, data: &bytes.Buffer{}}
+	for _, arg := range args {
+		msg.Write(arg)
+	}
+	return msg
+}
+
+// serveNewIds answers wl_display requests like serveSync and reports new
+// ids the server would reject: libwayland only accepts a recycled id or
+// the next unused one.
+func serveNewIds(server *net.UnixConn, errs chan<- error) {
+	defer close(errs)
+	next := ProxyId(2)
+	free := make(map[ProxyId]bool)
+	var serial uint32
+	for {
+		msg, err := ReadWaylandMessage(server)
+		if err != nil {
+			return
+		}
+		arg, _ := msg.GetUint32()
+		id := ProxyId(arg)
+		switch {
+		case id == next:
+			next++
+		case free[id]:
+			delete(free, id)
+		default:
+			select {
+			case errs <- fmt.Errorf("Invalid new id %d, next is %d", id, next):
+			default:
+			}
+		}
+		if msg.Opcode != 0 {
+			continue
+		}
+		serial++
+		free[id] = true
+		for _, ev := range []*Message{newEvent(id, 0, serial), newEvent(1, 1, uint32(id))} {
+			if SendWaylandMessage(server, ev) != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	const workers = 16
+	rounds := 500
+	if testing.Short() {
+		rounds = 100
+	}
+	for _, opts := range [][]Option{nil, {WithBatching()}} {
+		display, server := newTestDisplay(t, opts...)
+		errs := make(chan error, 1)
+		go serveNewIds(server, errs)
+		ctx := display.Connection()
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < rounds; i++ {
+					if i%workers == w {
+						registry, err := display.GetRegistry()
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						ctx.SetQueuePolicy(registry, QueueDropUnsubscribed, 0)
+						ctx.Unregister(registry)
+					}
+					if err := display.Roundtrip(context.Background()); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		ctx.Close()
+		for err := range errs {
+			t.Error(err)
+		}
+	}
+}
+
+func TestConcurrentRegister(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	ctx := display.Connection()
+	sink, probe := NewCallback(ctx), NewCallback(ctx)
+	ctx.register(sink)
+	ctx.register(probe)
+	ctx.Unregister(sink)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				cb := NewCallback(ctx)
+				ctx.register(cb)
+				ctx.Unregister(cb)
+				ctx.deleteId(cb.Id())
+			}
+		}()
+	}
+	// the dispatcher looks up objects while they are being registered
+	for i := 0; i < 200; i++ {
+		sendEvent(t, server, sink.Id(), 0, uint32(i))
+	}
+	sendEvent(t, server, probe.Id(), 0, uint32(0))
+	wg.Wait()
+	if _, err := probe.Wait(context.Background()); err != nil {
+		t.Fatalf("Connection failed: %s", err)
+	}
+}
+
+func TestCallbackIdsRecycled(t *testing.T) {
+	const window = 64
+	count := 1000000
+	if testing.Short() {
+		count = 10000
+	}
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go serveSync(server)
+
+	ctx := display.Connection()
+	for created := 0; created < count; created += window {
+		callbacks := make([]*Callback, window)
+		for i := range callbacks {
+			cb, err := display.Sync()
+			if err != nil {
+				t.Fatalf("Sync request failed: %s", err)
+			}
+			callbacks[i] = cb
+		}
+		for _, cb := range callbacks {
+			if _, err := cb.Wait(context.Background()); err != nil {
+				t.Fatalf("Waiting for callback failed: %s", err)
+			}
+		}
+	}
+	if err := display.Roundtrip(context.Background()); err != nil {
+		t.Fatalf("Roundtrip failed: %s", err)
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.currentId > window+2 {
+		t.Errorf("Object ids not recycled, highest id is %d", ctx.currentId)
+	}
+	if len(ctx.objects) > 2 {
+		t.Errorf("Deleted objects still registered: %d", len(ctx.objects))
+	}
+}
+
+func TestAllocateIdReusesFreedIds(t *testing.T) {
+	ctx := newConnection(nil)
+	newCallback := func() *Callback {
+		cb := NewCallback(ctx)
+		ctx.register(cb)
+		return cb
+	}
+	a, b := newCallback(), newCallback()
+	ctx.deleteId(a.Id())
+	ctx.deleteId(a.Id())
+	if c := newCallback(); c.Id() != a.Id() {
+		t.Errorf("Expected id %d to be reused, got %d", a.Id(), c.Id())
+	}
+	if c := newCallback(); c.Id() != b.Id()+1 {
+		t.Errorf("Expected fresh id %d, got %d", b.Id()+1, c.Id())
+	}
+	ctx.Unregister(b)
+	if c := newCallback(); c.Id() == b.Id() {
+		t.Error("Id reused before delete_id")
+	}
+}
+
+func TestConnectionEOF(t *testing.T) {
+	display, server := newTestDisplay(t)
+	ctx := display.Connection()
+	if ctx.Err() != nil {
+		t.Fatalf("Unexpected error on live connection: %s", ctx.Err())
+	}
+	server.Close()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Connection not terminated on EOF")
+	}
+	if !errors.Is(ctx.Err(), io.EOF) {
+		t.Errorf("Expected EOF error, got %v", ctx.Err())
+	}
+}
+
+func TestConnectionCloseWhileReading(t *testing.T) {
+	display, _ := newTestDisplay(t)
+	ctx := display.Connection()
+	closed := make(chan error)
+	go func() { closed <- ctx.Close() }()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked")
+	}
+	<-ctx.Done()
+	if ctx.Err() != ErrConnectionClosed {
+		t.Errorf("Expected ErrConnectionClosed, got %v", ctx.Err())
+	}
+}
+
+func TestConnectionUnknownObject(t *testing.T) {
+	display, server := newTestDisplay(t)
+	sendEvent(t, server, 42, 0)
+	select {
+	case <-display.Connection().Done():
+	case <-time.After(time.Second):
+		t.Fatal("Event for unknown object not reported")
+	}
+	if display.Connection().Err() == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestManualDispatch(t *testing.T) {
+	display, server := newTestDisplay(t, WithManualDispatch())
+	defer display.Connection().Close()
+	cb := NewCallback(display.Connection())
+	display.Connection().register(cb)
+	sendEvent(t, server, cb.Id(), 0, uint32(7))
+
+	select {
+	case <-cb.DoneChan:
+		t.Fatal("Event dispatched without request")
+	case <-time.After(20 * time.Millisecond):
+	}
+	display.Connection().Dispatch() <- true
+	select {
+	case ev := <-cb.DoneChan:
+		if ev.CallbackData != 7 {
+			t.Errorf("Unexpected callback data %d", ev.CallbackData)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Requested event not dispatched")
+	}
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestDestroyedProxy(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	ctx := display.Connection()
+	surface := NewSurface(ctx)
+	ctx.register(surface)
+	if err := surface.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if err := surface.Commit(); err != ErrProxyDestroyed {
+		t.Errorf("Unexpected error for request on destroyed surface: %v", err)
+	}
+	if err := surface.Destroy(); err != ErrProxyDestroyed {
+		t.Errorf("Unexpected error for second destroy: %v", err)
+	}
+
+	// ids of server created objects are free again immediately
+	offer := NewDataOffer(ctx)
+	if err := ctx.RegisterWithId(offer, serverIdStart); err != nil {
+		t.Fatal(err)
+	}
+	if err := offer.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.RegisterWithId(NewDataOffer(ctx), serverIdStart); err != nil {
+		t.Errorf("Id of destroyed data offer not reusable: %s", err)
+	}
+
+	id := surface.Id()
+	ctx.deleteId(id)
+	if _, ok := ctx.objects[id]; ok {
+		t.Error("Zombie not removed by delete_id")
+	}
+	if len(ctx.zombies) != 0 {
+		t.Errorf("%d zombies left", len(ctx.zombies))
+	}
+}
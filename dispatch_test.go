@@ -0,0 +1,197 @@
+package wayland
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// reflectDispatch is the reflection based dispatcher the generated
+// Dispatch methods replaced. It relies on the channel for opcode N being
+// field N+1 of the proxy struct and is kept to compare against.
+func reflectDispatch(proxy Proxy, m *Message) error {
+	f, ev, err := decodeEvent(proxy, m)
+	if err != nil {
+		return err
+	}
+	t := reflect.TypeOf(proxy).Elem()
+	name := t.Name() + "." + t.Field(int(m.Opcode)+1).Name
+	c := proxy.Connection()
+	if !c.events.push(proxy, f.Interface(), name, reflectSender(f, ev), func() { closeFds(ev.Interface()) }) {
+		return c.Err()
+	}
+	return nil
+}
+
+// decodeEvent unmarshals the message into the event struct of the
+// proxy's channel for the opcode, returning both the channel and event.
+// It does not support new_id arguments.
+func decodeEvent(proxy Proxy, m *Message) (reflect.Value, reflect.Value, error) {
+	v := reflect.ValueOf(proxy)
+	field := int(m.Opcode) + 1 // +1 because of BaseProxy
+	if field >= v.Elem().NumField() || v.Elem().Field(field).Kind() != reflect.Chan {
+		return reflect.Value{}, reflect.Value{}, corrupted("invalid opcode %d for object %d", m.Opcode, m.Id)
+	}
+	f := v.Elem().Field(field)
+	t := f.Type().Elem()
+	ev := reflect.New(t)
+	el := ev.Elem()
+	for i := 0; i < el.NumField(); i++ {
+		ef := el.Field(i)
+		var (
+			val interface{}
+			err error
+		)
+		switch ef.Kind() {
+		case reflect.Int32:
+			val, err = m.GetInt32()
+		case reflect.Uint32:
+			val, err = m.GetUint32()
+		case reflect.String:
+			val, err = m.GetString()
+		case reflect.Slice:
+			val, err = m.GetArray()
+		case reflect.Uintptr:
+			val, err = m.GetFD()
+		case reflect.Interface, reflect.Ptr:
+			val, err = m.GetProxy(proxy.Connection())
+		default:
+			return f, el, fmt.Errorf("Not handled field type: %s", ef.Kind())
+		}
+		if err != nil {
+			return f, el, err
+		}
+		if val == nil {
+			// null object argument
+			continue
+		}
+		fv := reflect.ValueOf(val)
+		if fv.Kind() == ef.Kind() && fv.Type().ConvertibleTo(ef.Type()) {
+			// enum types
+			fv = fv.Convert(ef.Type())
+		}
+		if !fv.Type().AssignableTo(ef.Type()) {
+			return f, el, corrupted("unexpected %s argument for %s.%s", fv.Type(), t.Name(), el.Type().Field(i).Name)
+		}
+		ef.Set(fv)
+	}
+	return f, el, nil
+}
+
+// reflectSender returns an eventSender sending ev on the channel f.
+func reflectSender(f reflect.Value, ev reflect.Value) eventSender {
+	return func(stop <-chan struct{}, stall <-chan time.Time) sendResult {
+		if f.TrySend(ev) {
+			return sendDelivered
+		}
+		chosen, _, _ := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: f, Send: ev},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stop)},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stall)},
+		})
+		return sendResult(chosen)
+	}
+}
+
+func TestDispatchMatchesReflection(t *testing.T) {
+	ctx := newConnection(nil)
+	surface, output, keyboard, pointer := NewSurface(ctx), NewOutput(ctx), NewKeyboard(ctx), NewPointer(ctx)
+	for _, p := range []Proxy{surface, output, keyboard, pointer} {
+		ctx.register(p)
+	}
+	tests := []struct {
+		proxy   Proxy
+		event   *Message
+		receive func() interface{}
+	}{
+		{output, newEvent(output.Id(), 0, int32(10), int32(20), int32(300), int32(200), int32(OutputSubpixelNone), "make", "model", int32(OutputTransform90)),
+			func() interface{} { return <-output.GeometryChan }},
+		{output, newEvent(output.Id(), 3, int32(2)),
+			func() interface{} { return <-output.ScaleChan }},
+		{keyboard, newEvent(keyboard.Id(), 1, uint32(5), Proxy(surface), uint32(8), uint32(30), uint32(31)),
+			func() interface{} { return <-keyboard.EnterChan }},
+		{keyboard, newEvent(keyboard.Id(), 3, uint32(6), uint32(1000), uint32(30), uint32(KeyboardKeyStatePressed)),
+			func() interface{} { return <-keyboard.KeyChan }},
+		{surface, newEvent(surface.Id(), 0, Proxy(output)),
+			func() interface{} { return <-surface.EnterChan }},
+		{surface, newEvent(surface.Id(), 3, uint32(OutputTransformFlipped)),
+			func() interface{} { return <-surface.PreferredBufferTransformChan }},
+		{pointer, newEvent(pointer.Id(), 9, uint32(PointerAxisHorizontalScroll), int32(-60)),
+			func() interface{} { return <-pointer.AxisValue120Chan }},
+		{output, newEvent(output.Id(), 4, "DP-1"),
+			func() interface{} { return <-output.NameChan }},
+	}
+	for _, test := range tests {
+		data := test.event.data.Bytes()
+		_, want, err := decodeEvent(test.proxy, &Message{Opcode: test.event.Opcode, data: bytes.NewBuffer(data)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		go test.proxy.Dispatch(test.event.Opcode, &Message{Opcode: test.event.Opcode, data: bytes.NewBuffer(data)})
+		if got := test.receive(); !reflect.DeepEqual(got, want.Interface()) {
+			t.Errorf("Dispatch decoded %+v, expected %+v", got, want.Interface())
+		}
+	}
+}
+
+// coreProxies returns one proxy of every interface of the core protocol.
+func coreProxies(ctx *Connection) []Proxy {
+	return []Proxy{NewDisplay(ctx), NewRegistry(ctx), NewCallback(ctx), NewCompositor(ctx),
+		NewShmPool(ctx), NewShm(ctx), NewBuffer(ctx), NewDataOffer(ctx), NewDataSource(ctx),
+		NewDataDevice(ctx), NewDataDeviceManager(ctx), NewShell(ctx), NewShellSurface(ctx),
+		NewSurface(ctx), NewSeat(ctx), NewPointer(ctx), NewKeyboard(ctx), NewTouch(ctx),
+		NewOutput(ctx), NewRegion(ctx), NewSubcompositor(ctx), NewSubsurface(ctx)}
+}
+
+func TestDescriptorsMatchProxies(t *testing.T) {
+	for _, p := range coreProxies(newConnection(nil)) {
+		ifc := p.Interface()
+		if chans := reflect.TypeOf(p).Elem().NumField() - 1; chans != len(ifc.Events) {
+			t.Errorf("%s: %d event channels, %d events described", ifc.Name, chans, len(ifc.Events))
+		}
+		if ifc.Version == 0 {
+			t.Errorf("%s: missing version", ifc.Name)
+		}
+	}
+	if sig := registryInterface.Requests[0].Signature(); sig != "usun" {
+		t.Errorf("Unexpected wl_registry.bind signature %q", sig)
+	}
+}
+
+// benchmarkDispatch delivers wl_pointer.motion events to a reader.
+func benchmarkDispatch(b *testing.B, dispatch func(Proxy, *Message) error) {
+	ctx := newConnection(nil)
+	pointer := NewPointer(ctx)
+	ctx.register(pointer)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-pointer.MotionChan:
+			case <-done:
+				return
+			}
+		}
+	}()
+	data := newEvent(pointer.Id(), 2, uint32(1000), FixedFromFloat64(10.5), FixedFromFloat64(20.25)).data.Bytes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := dispatch(pointer, &Message{Opcode: 2, data: bytes.NewBuffer(data)}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDispatchGenerated(b *testing.B) {
+	benchmarkDispatch(b, func(p Proxy, m *Message) error {
+		return p.Dispatch(m.Opcode, m)
+	})
+}
+
+func BenchmarkDispatchReflection(b *testing.B) {
+	benchmarkDispatch(b, reflectDispatch)
+}
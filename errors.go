@@ -0,0 +1,68 @@
+package wayland
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrConnectionCorrupted is wrapped by all errors caused by malformed data
+// received from the server. The connection is unusable afterwards.
+var ErrConnectionCorrupted = errors.New("Wayland connection corrupted.")
+
+// ProtocolError is the fatal error reported by wl_display.error. Once it
+// arrived all requests and waits on the connection fail with it.
+type ProtocolError struct {
+	Interface string
+	ObjectId  ProxyId
+	Code      uint32
+	CodeName  string
+	Message   string
+}
+
+func (e *ProtocolError) Error() string {
+	code := fmt.Sprint(e.Code)
+	if e.CodeName != "" {
+		code = e.CodeName
+	}
+	return fmt.Sprintf("Protocol error %s on %s@%d: %s", code, e.Interface, e.ObjectId, e.Message)
+}
+
+// VersionError is returned for requests the object does not support with
+// the version it was bound with. Nothing is sent to the server.
+type VersionError struct {
+	Interface string
+	Request   string
+	Since     uint32
+	Version   uint32
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("Request %s.%s needs version %d, object has version %d.", e.Interface, e.Request, e.Since, e.Version)
+}
+
+func newProtocolError(ev DisplayErrorEvent) *ProtocolError {
+	err := &ProtocolError{Code: ev.Code, Message: ev.Message}
+	if ev.ObjectId != nil {
+		ifc := ev.ObjectId.Interface()
+		err.Interface = ifc.Name
+		err.ObjectId = ev.ObjectId.Id()
+		err.CodeName = ifc.Errors[ev.Code]
+	}
+	return err
+}
+
+// MissingGlobalsError lists the interfaces the server does not advertise.
+type MissingGlobalsError struct {
+	Interfaces []string
+	// Err is the context error if waiting for the globals was cancelled.
+	Err error
+}
+
+func (e *MissingGlobalsError) Error() string {
+	return fmt.Sprintf("Missing globals: %s.", strings.Join(e.Interfaces, ", "))
+}
+
+func (e *MissingGlobalsError) Unwrap() error {
+	return e.Err
+}
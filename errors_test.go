@@ -0,0 +1,70 @@
+package wayland
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProtocolErrorFailsRequests(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	shm := NewShm(display.Connection())
+	display.Connection().register(shm)
+	pool := NewShmPool(display.Connection())
+	display.Connection().register(pool)
+	cb := NewCallback(display.Connection())
+	display.Connection().register(cb)
+	sendEvent(t, server, 1, 0, Proxy(shm), uint32(ShmErrorInvalidStride), "invalid stride")
+
+	_, err := cb.Wait(context.Background())
+	var perr *ProtocolError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected a protocol error, got %v", err)
+	}
+	want := ProtocolError{"wl_shm", shm.Id(), uint32(ShmErrorInvalidStride), "ShmErrorInvalidStride", "invalid stride"}
+	if *perr != want {
+		t.Errorf("Expected %+v, got %+v", want, *perr)
+	}
+	if err := pool.Resize(4096); err != perr {
+		t.Errorf("Request on dead connection returned %v", err)
+	}
+	if _, err := display.Sync(); err != perr {
+		t.Errorf("Request on dead connection returned %v", err)
+	}
+}
+
+func TestProtocolErrorDeliveredOnErrorChan(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	errs := make(chan DisplayErrorEvent)
+	go func() { errs <- <-display.ErrorChan }()
+	time.Sleep(10 * time.Millisecond)
+	sendEvent(t, server, 1, 0, Proxy(display), uint32(DisplayErrorNoMemory), "no memory")
+	select {
+	case ev := <-errs:
+		if ev.Code != uint32(DisplayErrorNoMemory) {
+			t.Errorf("Unexpected error code %d", ev.Code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error event not delivered")
+	}
+}
+
+func TestProtocolErrorDeliveredToLateReader(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	sendEvent(t, server, 1, 0, Proxy(display), uint32(DisplayErrorNoMemory), "no memory")
+	<-display.Connection().Done()
+	// the display is queued, the event must survive the shutdown
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case ev := <-display.ErrorChan:
+		if ev.Code != uint32(DisplayErrorNoMemory) {
+			t.Errorf("Unexpected error code %d", ev.Code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error event lost on shutdown")
+	}
+}
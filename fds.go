@@ -0,0 +1,69 @@
+package wayland
+
+import (
+	"sync"
+	"syscall"
+)
+
+// maxFds is the number of file descriptors libwayland sends at most with
+// a single sendmsg.
+const maxFds = 28
+
+// fdQueue holds received file descriptors until messages consume them in
+// argument order, regardless of the recvmsg they arrived with.
+type fdQueue struct {
+	mu  sync.Mutex
+	fds []int
+}
+
+func (q *fdQueue) push(fds ...int) {
+	q.mu.Lock()
+	q.fds = append(q.fds, fds...)
+	q.mu.Unlock()
+}
+
+func (q *fdQueue) pop() (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.fds) == 0 {
+		return -1, false
+	}
+	fd := q.fds[0]
+	q.fds = q.fds[1:]
+	return fd, true
+}
+
+func (q *fdQueue) closeAll() {
+	q.mu.Lock()
+	for _, fd := range q.fds {
+		syscall.Close(fd)
+	}
+	q.fds = nil
+	q.mu.Unlock()
+}
+
+// newControlBuffer returns a buffer big enough for maxFds descriptors.
+func newControlBuffer() []byte {
+	return make([]byte, syscall.CmsgSpace(maxFds*4))
+}
+
+// receive moves all descriptors of a received control buffer to q.
+func (q *fdQueue) receive(control []byte, flags int) error {
+	if len(control) > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(control)
+		if err != nil {
+			return corrupted("control message parse error: %s", err)
+		}
+		for i := range msgs {
+			fds, err := syscall.ParseUnixRights(&msgs[i])
+			if err != nil {
+				return corrupted("unable to parse unix rights: %s", err)
+			}
+			q.push(fds...)
+		}
+	}
+	if flags&syscall.MSG_CTRUNC != 0 {
+		return corrupted("control message truncated")
+	}
+	return nil
+}
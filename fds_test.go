@@ -0,0 +1,271 @@
+package wayland
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// tempFiles creates n distinct files, identified by their inode.
+func tempFiles(t *testing.T, n int) []*os.File {
+	var files []*os.File
+	for i := 0; i < n; i++ {
+		f, err := os.CreateTemp(t.TempDir(), "fd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		files = append(files, f)
+	}
+	return files
+}
+
+func sameFile(t *testing.T, fd uintptr, f *os.File) bool {
+	var a, b syscall.Stat_t
+	if err := syscall.Fstat(int(fd), &a); err != nil {
+		t.Fatalf("Received invalid fd %d: %s", fd, err)
+	}
+	syscall.Fstat(int(f.Fd()), &b)
+	syscall.Close(int(fd))
+	return a.Ino == b.Ino && a.Dev == b.Dev
+}
+
+// wireFormat encodes events the way SendWaylandMessage does, without fds.
+func wireFormat(msgs ...*Message) []byte {
+	var wire bytes.Buffer
+	for _, msg := range msgs {
+		msg.marshal(&wire)
+	}
+	return wire.Bytes()
+}
+
+func receiveFd(t *testing.T, ch <-chan KeyboardKeymapEvent) uintptr {
+	select {
+	case ev := <-ch:
+		return ev.Fd
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for keymap")
+	}
+	return 0
+}
+
+func TestFdsInOneBurst(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	keyboard := NewKeyboard(display.Connection())
+	display.Connection().register(keyboard)
+	source := NewDataSource(display.Connection())
+	display.Connection().register(source)
+	files := tempFiles(t, maxFds)
+
+	var msgs []*Message
+	var fds []int
+	for i, f := range files {
+		if i == 1 {
+			msgs = append(msgs, newEvent(source.Id(), 1, "text/plain"))
+		} else {
+			msgs = append(msgs, newEvent(keyboard.Id(), 0, uint32(KeyboardKeymapFormatXkbV1), uint32(4096)))
+		}
+		fds = append(fds, int(f.Fd()))
+	}
+	if _, _, err := server.WriteMsgUnix(wireFormat(msgs...), syscall.UnixRights(fds...), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, f := range files {
+		var fd uintptr
+		if i == 1 {
+			ev := <-source.SendChan
+			if ev.MimeType != "text/plain" {
+				t.Errorf("Unexpected mime type %q", ev.MimeType)
+			}
+			fd = ev.Fd
+		} else {
+			fd = receiveFd(t, keyboard.KeymapChan)
+		}
+		if !sameFile(t, fd, f) {
+			t.Errorf("Fd %d handed to the wrong message", i)
+		}
+	}
+}
+
+func TestFdsBeforeMessageCompleted(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	keyboard := NewKeyboard(display.Connection())
+	display.Connection().register(keyboard)
+	files := tempFiles(t, 2)
+
+	wire := wireFormat(
+		newEvent(keyboard.Id(), 0, uint32(KeyboardKeymapFormatXkbV1), uint32(4096)),
+		newEvent(keyboard.Id(), 0, uint32(KeyboardKeymapFormatXkbV1), uint32(4096)))
+	// both fds travel with the first bytes of the first message
+	rights := syscall.UnixRights(int(files[0].Fd()), int(files[1].Fd()))
+	if _, _, err := server.WriteMsgUnix(wire[:4], rights, nil); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	server.Write(wire[4:])
+
+	for i, f := range files {
+		if !sameFile(t, receiveFd(t, keyboard.KeymapChan), f) {
+			t.Errorf("Fd %d handed to the wrong message", i)
+		}
+	}
+}
+
+func TestMessageMultipleFds(t *testing.T) {
+	// dmabuf style message carrying one fd per plane
+	files := tempFiles(t, 4)
+	q := &fdQueue{}
+	for _, f := range files {
+		fd, err := syscall.Dup(int(f.Fd()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		q.push(fd)
+	}
+	m := newTestMessage()
+	m.fds = q
+	for i, f := range files {
+		fd, err := m.GetFD()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sameFile(t, fd, f) {
+			t.Errorf("Plane %d got the wrong fd", i)
+		}
+	}
+	if _, err := m.GetFD(); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("Expected missing fd error, got %v", err)
+	}
+}
+
+func TestZombieEventsCloseFds(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	keyboard := NewKeyboard(display.Connection())
+	keyboard.SetVersion(3)
+	display.Connection().register(keyboard)
+	live := NewKeyboard(display.Connection())
+	display.Connection().register(live)
+	if err := keyboard.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the keymap in flight when the keyboard was released
+	var p [2]int
+	if err := syscall.Pipe2(p[:], syscall.O_CLOEXEC); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(p[0])
+	wire := wireFormat(
+		newEvent(keyboard.Id(), 0, uint32(KeyboardKeymapFormatXkbV1), uint32(4096)),
+		newEvent(keyboard.Id(), 4, uint32(7), uint32(0), uint32(0), uint32(0), uint32(0)),
+		newEvent(live.Id(), 4, uint32(8), uint32(0), uint32(0), uint32(0), uint32(0)))
+	if _, _, err := server.WriteMsgUnix(wire, syscall.UnixRights(p[1]), nil); err != nil {
+		t.Fatal(err)
+	}
+	syscall.Close(p[1])
+
+	// EOF once the last write end, the one received, is closed
+	done := make(chan error, 1)
+	go func() {
+		_, err := syscall.Read(p[0], make([]byte, 1))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Fd of zombie event not closed")
+	}
+	select {
+	case <-live.ModifiersChan:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for events after the zombie events")
+	}
+	select {
+	case ev := <-keyboard.ModifiersChan:
+		t.Errorf("Event delivered to zombie: %+v", ev)
+	default:
+	}
+	if err := display.Connection().Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnregisteredEventsCloseFds(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	unregistered := NewKeyboard(display.Connection())
+	unregistered.SetVersion(3)
+	display.Connection().register(unregistered)
+	live := NewKeyboard(display.Connection())
+	display.Connection().register(live)
+	display.Connection().Unregister(unregistered)
+
+	files := tempFiles(t, 2)
+	wire := wireFormat(
+		newEvent(unregistered.Id(), 0, uint32(KeyboardKeymapFormatXkbV1), uint32(4096)),
+		newEvent(live.Id(), 0, uint32(KeyboardKeymapFormatXkbV1), uint32(4096)))
+	rights := syscall.UnixRights(int(files[0].Fd()), int(files[1].Fd()))
+	if _, _, err := server.WriteMsgUnix(wire, rights, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !sameFile(t, receiveFd(t, live.KeymapChan), files[1]) {
+		t.Error("Keymap of the live keyboard got the fd of the unregistered one")
+	}
+	if err := unregistered.Release(); err != ErrProxyDestroyed {
+		t.Errorf("Unexpected error for request on unregistered keyboard: %v", err)
+	}
+}
+
+func TestDestroyDropsQueuedFds(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	ctx := display.Connection()
+	keyboard := NewKeyboard(ctx)
+	keyboard.SetVersion(3)
+	ctx.register(keyboard)
+	ctx.SetQueuePolicy(keyboard, QueueBuffer, 1)
+	probe := NewCallback(ctx)
+	ctx.register(probe)
+
+	// nobody reads the keymaps: one waits in the pump, one in the queue
+	// and one holds up the dispatcher
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	keymap := newEvent(keyboard.Id(), 0, uint32(KeyboardKeymapFormatXkbV1), uint32(4096))
+	wire := wireFormat(keymap, keymap, keymap)
+	fd := int(w.Fd())
+	if _, _, err := server.WriteMsgUnix(wire, syscall.UnixRights(fd, fd, fd), nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	time.Sleep(20 * time.Millisecond)
+	if err := keyboard.Release(); err != nil {
+		t.Fatal(err)
+	}
+	sendEvent(t, server, probe.Id(), 0, uint32(0))
+	if _, err := probe.Wait(testContext(t)); err != nil {
+		t.Fatalf("Dispatcher still blocked by the destroyed keyboard: %s", err)
+	}
+	// the pipe reports EOF once all copies of the write end are closed
+	r.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Fds of queued keymaps not closed: %v", err)
+	}
+}
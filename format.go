@@ -0,0 +1,376 @@
+package wayland
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Block is the unit in which a plane stores pixels, Bytes for Width times
+// Height pixels. Most formats store single pixels.
+type Block struct {
+	Bytes, Width, Height int
+}
+
+// FormatInfo describes the memory layout of a ShmFormat.
+type FormatInfo struct {
+	// Name is the DRM name of the format, e.g. ARGB8888.
+	Name   string
+	Planes int
+	Blocks [4]Block
+	// HSub and VSub are the horizontal and vertical subsampling of all
+	// planes but the first.
+	HSub, VSub int
+	Alpha      bool
+	YUV        bool
+}
+
+// BytesPerPixel returns the average size of a pixel in the plane, zero
+// for compressed formats without a linear layout.
+func (f FormatInfo) BytesPerPixel(plane int) float64 {
+	b := f.Blocks[plane]
+	if b.Bytes == 0 {
+		return 0
+	}
+	return float64(b.Bytes) / float64(b.Width*b.Height)
+}
+
+// MinStride returns the number of bytes of a row of the plane for an
+// image of the given width.
+func (f FormatInfo) MinStride(plane, width int) int {
+	if plane > 0 {
+		width = (width + f.HSub - 1) / f.HSub
+	}
+	b := f.Blocks[plane]
+	return (width + b.Width - 1) / b.Width * b.Bytes
+}
+
+// Info returns the layout of the format.
+func (f ShmFormat) Info() (FormatInfo, bool) {
+	info, ok := formatInfos[f]
+	if ok {
+		info.Name = strings.ToUpper(f.String())
+	}
+	return info, ok
+}
+
+type formatFlags int
+
+const (
+	hasAlpha formatFlags = 1 << iota
+	isYUV
+)
+
+// planarInfo returns the info of a format storing single pixels with the
+// given sizes in each plane.
+func planarInfo(flags formatFlags, hsub, vsub int, bytes ...int) FormatInfo {
+	info := FormatInfo{Planes: len(bytes), HSub: hsub, VSub: vsub, Alpha: flags&hasAlpha != 0, YUV: flags&isYUV != 0}
+	for i, b := range bytes {
+		info.Blocks[i] = Block{b, 1, 1}
+	}
+	return info
+}
+
+func packedInfo(flags formatFlags, bytes int) FormatInfo {
+	return planarInfo(flags, 1, 1, bytes)
+}
+
+// blockInfo returns the info of a single plane format storing blocks.
+func blockInfo(flags formatFlags, hsub, vsub int, b Block) FormatInfo {
+	info := planarInfo(flags, hsub, vsub, 0)
+	info.Blocks[0] = b
+	return info
+}
+
+var formatInfos = map[ShmFormat]FormatInfo{
+	ShmFormatC1:     blockInfo(0, 1, 1, Block{1, 8, 1}),
+	ShmFormatC2:     blockInfo(0, 1, 1, Block{1, 4, 1}),
+	ShmFormatC4:     blockInfo(0, 1, 1, Block{1, 2, 1}),
+	ShmFormatC8:     packedInfo(0, 1),
+	ShmFormatD1:     blockInfo(0, 1, 1, Block{1, 8, 1}),
+	ShmFormatD2:     blockInfo(0, 1, 1, Block{1, 4, 1}),
+	ShmFormatD4:     blockInfo(0, 1, 1, Block{1, 2, 1}),
+	ShmFormatD8:     packedInfo(0, 1),
+	ShmFormatR1:     blockInfo(0, 1, 1, Block{1, 8, 1}),
+	ShmFormatR2:     blockInfo(0, 1, 1, Block{1, 4, 1}),
+	ShmFormatR4:     blockInfo(0, 1, 1, Block{1, 2, 1}),
+	ShmFormatR8:     packedInfo(0, 1),
+	ShmFormatR10:    packedInfo(0, 2),
+	ShmFormatR12:    packedInfo(0, 2),
+	ShmFormatR16:    packedInfo(0, 2),
+	ShmFormatRg88:   packedInfo(0, 2),
+	ShmFormatGr88:   packedInfo(0, 2),
+	ShmFormatRg1616: packedInfo(0, 4),
+	ShmFormatGr1616: packedInfo(0, 4),
+
+	ShmFormatRgb332:               packedInfo(0, 1),
+	ShmFormatBgr233:               packedInfo(0, 1),
+	ShmFormatXrgb4444:             packedInfo(0, 2),
+	ShmFormatXbgr4444:             packedInfo(0, 2),
+	ShmFormatRgbx4444:             packedInfo(0, 2),
+	ShmFormatBgrx4444:             packedInfo(0, 2),
+	ShmFormatArgb4444:             packedInfo(hasAlpha, 2),
+	ShmFormatAbgr4444:             packedInfo(hasAlpha, 2),
+	ShmFormatRgba4444:             packedInfo(hasAlpha, 2),
+	ShmFormatBgra4444:             packedInfo(hasAlpha, 2),
+	ShmFormatXrgb1555:             packedInfo(0, 2),
+	ShmFormatXbgr1555:             packedInfo(0, 2),
+	ShmFormatRgbx5551:             packedInfo(0, 2),
+	ShmFormatBgrx5551:             packedInfo(0, 2),
+	ShmFormatArgb1555:             packedInfo(hasAlpha, 2),
+	ShmFormatAbgr1555:             packedInfo(hasAlpha, 2),
+	ShmFormatRgba5551:             packedInfo(hasAlpha, 2),
+	ShmFormatBgra5551:             packedInfo(hasAlpha, 2),
+	ShmFormatRgb565:               packedInfo(0, 2),
+	ShmFormatBgr565:               packedInfo(0, 2),
+	ShmFormatRgb888:               packedInfo(0, 3),
+	ShmFormatBgr888:               packedInfo(0, 3),
+	ShmFormatXrgb8888:             packedInfo(0, 4),
+	ShmFormatXbgr8888:             packedInfo(0, 4),
+	ShmFormatRgbx8888:             packedInfo(0, 4),
+	ShmFormatBgrx8888:             packedInfo(0, 4),
+	ShmFormatArgb8888:             packedInfo(hasAlpha, 4),
+	ShmFormatAbgr8888:             packedInfo(hasAlpha, 4),
+	ShmFormatRgba8888:             packedInfo(hasAlpha, 4),
+	ShmFormatBgra8888:             packedInfo(hasAlpha, 4),
+	ShmFormatXrgb2101010:          packedInfo(0, 4),
+	ShmFormatXbgr2101010:          packedInfo(0, 4),
+	ShmFormatRgbx1010102:          packedInfo(0, 4),
+	ShmFormatBgrx1010102:          packedInfo(0, 4),
+	ShmFormatArgb2101010:          packedInfo(hasAlpha, 4),
+	ShmFormatAbgr2101010:          packedInfo(hasAlpha, 4),
+	ShmFormatRgba1010102:          packedInfo(hasAlpha, 4),
+	ShmFormatBgra1010102:          packedInfo(hasAlpha, 4),
+	ShmFormatXrgb16161616:         packedInfo(0, 8),
+	ShmFormatXbgr16161616:         packedInfo(0, 8),
+	ShmFormatArgb16161616:         packedInfo(hasAlpha, 8),
+	ShmFormatAbgr16161616:         packedInfo(hasAlpha, 8),
+	ShmFormatXrgb16161616f:        packedInfo(0, 8),
+	ShmFormatXbgr16161616f:        packedInfo(0, 8),
+	ShmFormatArgb16161616f:        packedInfo(hasAlpha, 8),
+	ShmFormatAbgr16161616f:        packedInfo(hasAlpha, 8),
+	ShmFormatAxbxgxrx106106106106: packedInfo(hasAlpha, 8),
+	ShmFormatXrgb8888A8:           planarInfo(hasAlpha, 1, 1, 4, 1),
+	ShmFormatXbgr8888A8:           planarInfo(hasAlpha, 1, 1, 4, 1),
+	ShmFormatRgbx8888A8:           planarInfo(hasAlpha, 1, 1, 4, 1),
+	ShmFormatBgrx8888A8:           planarInfo(hasAlpha, 1, 1, 4, 1),
+	ShmFormatRgb888A8:             planarInfo(hasAlpha, 1, 1, 3, 1),
+	ShmFormatBgr888A8:             planarInfo(hasAlpha, 1, 1, 3, 1),
+	ShmFormatRgb565A8:             planarInfo(hasAlpha, 1, 1, 2, 1),
+	ShmFormatBgr565A8:             planarInfo(hasAlpha, 1, 1, 2, 1),
+
+	ShmFormatYuyv:           blockInfo(isYUV, 2, 1, Block{4, 2, 1}),
+	ShmFormatYvyu:           blockInfo(isYUV, 2, 1, Block{4, 2, 1}),
+	ShmFormatUyvy:           blockInfo(isYUV, 2, 1, Block{4, 2, 1}),
+	ShmFormatVyuy:           blockInfo(isYUV, 2, 1, Block{4, 2, 1}),
+	ShmFormatAyuv:           packedInfo(isYUV|hasAlpha, 4),
+	ShmFormatXyuv8888:       packedInfo(isYUV, 4),
+	ShmFormatAvuy8888:       packedInfo(isYUV|hasAlpha, 4),
+	ShmFormatXvuy8888:       packedInfo(isYUV, 4),
+	ShmFormatVuy888:         packedInfo(isYUV, 3),
+	ShmFormatVuy101010:      blockInfo(isYUV, 1, 1, Block{}),
+	ShmFormatY210:           blockInfo(isYUV, 2, 1, Block{8, 2, 1}),
+	ShmFormatY212:           blockInfo(isYUV, 2, 1, Block{8, 2, 1}),
+	ShmFormatY216:           blockInfo(isYUV, 2, 1, Block{8, 2, 1}),
+	ShmFormatY410:           packedInfo(isYUV|hasAlpha, 4),
+	ShmFormatY412:           packedInfo(isYUV|hasAlpha, 8),
+	ShmFormatY416:           packedInfo(isYUV|hasAlpha, 8),
+	ShmFormatXvyu2101010:    packedInfo(isYUV, 4),
+	ShmFormatXvyu1216161616: packedInfo(isYUV, 8),
+	ShmFormatXvyu16161616:   packedInfo(isYUV, 8),
+	ShmFormatY0l0:           blockInfo(isYUV|hasAlpha, 2, 2, Block{8, 2, 2}),
+	ShmFormatX0l0:           blockInfo(isYUV, 2, 2, Block{8, 2, 2}),
+	ShmFormatY0l2:           blockInfo(isYUV|hasAlpha, 2, 2, Block{8, 2, 2}),
+	ShmFormatX0l2:           blockInfo(isYUV, 2, 2, Block{8, 2, 2}),
+	ShmFormatYuv4208bit:     blockInfo(isYUV, 2, 2, Block{}),
+	ShmFormatYuv42010bit:    blockInfo(isYUV, 2, 2, Block{}),
+
+	ShmFormatNv12:   planarInfo(isYUV, 2, 2, 1, 2),
+	ShmFormatNv21:   planarInfo(isYUV, 2, 2, 1, 2),
+	ShmFormatNv16:   planarInfo(isYUV, 2, 1, 1, 2),
+	ShmFormatNv61:   planarInfo(isYUV, 2, 1, 1, 2),
+	ShmFormatNv24:   planarInfo(isYUV, 1, 1, 1, 2),
+	ShmFormatNv42:   planarInfo(isYUV, 1, 1, 1, 2),
+	ShmFormatNv15:   {Planes: 2, Blocks: [4]Block{{5, 4, 1}, {5, 2, 1}}, HSub: 2, VSub: 2, YUV: true},
+	ShmFormatP210:   planarInfo(isYUV, 2, 1, 2, 4),
+	ShmFormatP010:   planarInfo(isYUV, 2, 2, 2, 4),
+	ShmFormatP012:   planarInfo(isYUV, 2, 2, 2, 4),
+	ShmFormatP016:   planarInfo(isYUV, 2, 2, 2, 4),
+	ShmFormatP030:   {Planes: 2, Blocks: [4]Block{{4, 3, 1}, {8, 3, 1}}, HSub: 2, VSub: 2, YUV: true},
+	ShmFormatYuv410: planarInfo(isYUV, 4, 4, 1, 1, 1),
+	ShmFormatYvu410: planarInfo(isYUV, 4, 4, 1, 1, 1),
+	ShmFormatYuv411: planarInfo(isYUV, 4, 1, 1, 1, 1),
+	ShmFormatYvu411: planarInfo(isYUV, 4, 1, 1, 1, 1),
+	ShmFormatYuv420: planarInfo(isYUV, 2, 2, 1, 1, 1),
+	ShmFormatYvu420: planarInfo(isYUV, 2, 2, 1, 1, 1),
+	ShmFormatYuv422: planarInfo(isYUV, 2, 1, 1, 1, 1),
+	ShmFormatYvu422: planarInfo(isYUV, 2, 1, 1, 1, 1),
+	ShmFormatYuv444: planarInfo(isYUV, 1, 1, 1, 1, 1),
+	ShmFormatYvu444: planarInfo(isYUV, 1, 1, 1, 1, 1),
+	ShmFormatQ410:   planarInfo(isYUV, 1, 1, 2, 2, 2),
+	ShmFormatQ401:   planarInfo(isYUV, 1, 1, 2, 2, 2),
+}
+
+// Frame is an image in any format supported by Convert, with the pixels
+// of each plane in a separate slice.
+type Frame struct {
+	Format        ShmFormat
+	Width, Height int
+	Planes        [3][]byte
+	Strides       [3]int
+}
+
+// NewFrame returns a frame over data, with the planes stored one after
+// another without padding, like a shm buffer with the offset and stride
+// of the first plane and the planes following it.
+func NewFrame(data []byte, width, height int, format ShmFormat) (*Frame, error) {
+	info, ok := format.Info()
+	if !ok || info.Planes > 3 || info.Blocks[0].Bytes == 0 {
+		return nil, fmt.Errorf("Format %s has no linear layout.", format)
+	}
+	f := &Frame{Format: format, Width: width, Height: height}
+	for i := 0; i < info.Planes; i++ {
+		rows := height
+		if i > 0 {
+			rows = (height + info.VSub - 1) / info.VSub
+		}
+		f.Strides[i] = info.MinStride(i, width)
+		size := f.Strides[i] * rows
+		if size > len(data) {
+			return nil, fmt.Errorf("%d bytes too small for a %dx%d frame in format %s.", len(data), width, height, format)
+		}
+		f.Planes[i], data = data[:size], data[size:]
+	}
+	return f, nil
+}
+
+// sample locates a byte of a YUV pixel as plane, offset and distance
+// between the bytes of horizontally adjacent pixels.
+type sample struct {
+	plane, offset, step int
+}
+
+// yuvLayout describes an 8 bit YUV format. Chroma samples are shared by
+// blocks of hsub×vsub pixels.
+type yuvLayout struct {
+	hsub, vsub int
+	y, u, v    sample
+}
+
+var yuvLayouts = map[ShmFormat]yuvLayout{
+	ShmFormatYuyv:   {2, 1, sample{0, 0, 2}, sample{0, 1, 4}, sample{0, 3, 4}},
+	ShmFormatYvyu:   {2, 1, sample{0, 0, 2}, sample{0, 3, 4}, sample{0, 1, 4}},
+	ShmFormatUyvy:   {2, 1, sample{0, 1, 2}, sample{0, 0, 4}, sample{0, 2, 4}},
+	ShmFormatVyuy:   {2, 1, sample{0, 1, 2}, sample{0, 2, 4}, sample{0, 0, 4}},
+	ShmFormatNv12:   {2, 2, sample{0, 0, 1}, sample{1, 0, 2}, sample{1, 1, 2}},
+	ShmFormatNv21:   {2, 2, sample{0, 0, 1}, sample{1, 1, 2}, sample{1, 0, 2}},
+	ShmFormatNv16:   {2, 1, sample{0, 0, 1}, sample{1, 0, 2}, sample{1, 1, 2}},
+	ShmFormatNv61:   {2, 1, sample{0, 0, 1}, sample{1, 1, 2}, sample{1, 0, 2}},
+	ShmFormatNv24:   {1, 1, sample{0, 0, 1}, sample{1, 0, 2}, sample{1, 1, 2}},
+	ShmFormatNv42:   {1, 1, sample{0, 0, 1}, sample{1, 1, 2}, sample{1, 0, 2}},
+	ShmFormatYuv420: {2, 2, sample{0, 0, 1}, sample{1, 0, 1}, sample{2, 0, 1}},
+	ShmFormatYvu420: {2, 2, sample{0, 0, 1}, sample{2, 0, 1}, sample{1, 0, 1}},
+	ShmFormatYuv422: {2, 1, sample{0, 0, 1}, sample{1, 0, 1}, sample{2, 0, 1}},
+	ShmFormatYvu422: {2, 1, sample{0, 0, 1}, sample{2, 0, 1}, sample{1, 0, 1}},
+	ShmFormatYuv444: {1, 1, sample{0, 0, 1}, sample{1, 0, 1}, sample{2, 0, 1}},
+	ShmFormatYvu444: {1, 1, sample{0, 0, 1}, sample{2, 0, 1}, sample{1, 0, 1}},
+}
+
+// lumaIndex and chromaIndex return the position of a sample of the pixel
+// at (x, y) in its plane.
+func (l yuvLayout) lumaIndex(f *Frame, x, y int) int {
+	return y*f.Strides[l.y.plane] + x*l.y.step + l.y.offset
+}
+
+func (l yuvLayout) chromaIndex(f *Frame, s sample, x, y int) int {
+	return y/l.vsub*f.Strides[s.plane] + x/l.hsub*s.step + s.offset
+}
+
+// rgbToYUV and yuvToRGB convert between 8 bit RGB and limited range
+// BT.601 YUV, the default of compositors for YUV buffers.
+func rgbToYUV(r, g, b int32) (y, u, v int32) {
+	y = (66*r+129*g+25*b+128)>>8 + 16
+	u = (-38*r-74*g+112*b+128)>>8 + 128
+	v = (112*r-94*g-18*b+128)>>8 + 128
+	return
+}
+
+func yuvToRGB(y, u, v int32) (r, g, b int32) {
+	c, d, e := 298*(y-16), u-128, v-128
+	return clamp8((c + 409*e + 128) >> 8), clamp8((c - 100*d - 208*e + 128) >> 8), clamp8((c + 516*d + 128) >> 8)
+}
+
+func clamp8(v int32) int32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+// Convert copies the pixels of src to dst, which must have the same size.
+// Both can be in any packed RGB format or in one of the 8 bit YUV formats
+// like Nv12, Yuyv and Yuv420. Alpha is dropped for YUV.
+func Convert(dst, src *Frame) error {
+	if dst.Width != src.Width || dst.Height != src.Height {
+		return fmt.Errorf("Frame sizes %dx%d and %dx%d differ.", dst.Width, dst.Height, src.Width, src.Height)
+	}
+	read, err := pixelReader(src)
+	if err != nil {
+		return err
+	}
+	if p, ok := packings[dst.Format]; ok {
+		for y := 0; y < dst.Height; y++ {
+			row := dst.Planes[0][y*dst.Strides[0]:]
+			for x := 0; x < dst.Width; x++ {
+				r, g, b, a := read(x, y)
+				p.store(row[x*p.bytes:], p.encode(r, g, b, a))
+			}
+		}
+		return nil
+	}
+	l, ok := yuvLayouts[dst.Format]
+	if !ok {
+		return fmt.Errorf("Conversion to format %s not supported.", dst.Format)
+	}
+	for by := 0; by < dst.Height; by += l.vsub {
+		for bx := 0; bx < dst.Width; bx += l.hsub {
+			// chroma is averaged over the pixels of the block
+			var us, vs, n int32
+			for y := by; y < by+l.vsub && y < dst.Height; y++ {
+				for x := bx; x < bx+l.hsub && x < dst.Width; x++ {
+					r, g, b, _ := read(x, y)
+					Y, U, V := rgbToYUV(int32(r>>8), int32(g>>8), int32(b>>8))
+					dst.Planes[l.y.plane][l.lumaIndex(dst, x, y)] = byte(Y)
+					us, vs, n = us+U, vs+V, n+1
+				}
+			}
+			dst.Planes[l.u.plane][l.chromaIndex(dst, l.u, bx, by)] = byte((us + n/2) / n)
+			dst.Planes[l.v.plane][l.chromaIndex(dst, l.v, bx, by)] = byte((vs + n/2) / n)
+		}
+	}
+	return nil
+}
+
+// pixelReader returns a function reading premultiplied 16 bit RGBA values
+// of the pixels of f.
+func pixelReader(f *Frame) (func(x, y int) (r, g, b, a uint32), error) {
+	if p, ok := packings[f.Format]; ok {
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			c := p.decode(p.load(f.Planes[0][y*f.Strides[0]+x*p.bytes:]))
+			return uint32(c.R), uint32(c.G), uint32(c.B), uint32(c.A)
+		}, nil
+	}
+	l, ok := yuvLayouts[f.Format]
+	if !ok {
+		return nil, fmt.Errorf("Conversion from format %s not supported.", f.Format)
+	}
+	return func(x, y int) (uint32, uint32, uint32, uint32) {
+		Y := f.Planes[l.y.plane][l.lumaIndex(f, x, y)]
+		U := f.Planes[l.u.plane][l.chromaIndex(f, l.u, x, y)]
+		V := f.Planes[l.v.plane][l.chromaIndex(f, l.v, x, y)]
+		r, g, b := yuvToRGB(int32(Y), int32(U), int32(V))
+		return uint32(r) * 0x101, uint32(g) * 0x101, uint32(b) * 0x101, 0xffff
+	}, nil
+}
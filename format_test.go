@@ -0,0 +1,218 @@
+package wayland
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFormatInfoComplete(t *testing.T) {
+	data, err := os.ReadFile("protocol/wayland.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var protocol struct {
+		Interfaces []struct {
+			Name  string `xml:"name,attr"`
+			Enums []struct {
+				Name    string `xml:"name,attr"`
+				Entries []struct {
+					Name  string `xml:"name,attr"`
+					Value string `xml:"value,attr"`
+				} `xml:"entry"`
+			} `xml:"enum"`
+		} `xml:"interface"`
+	}
+	if err := xml.Unmarshal(data, &protocol); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, ifc := range protocol.Interfaces {
+		for _, enum := range ifc.Enums {
+			if ifc.Name != "wl_shm" || enum.Name != "format" {
+				continue
+			}
+			for _, entry := range enum.Entries {
+				v, _ := strconv.ParseUint(entry.Value, 0, 32)
+				info, ok := ShmFormat(v).Info()
+				if !ok {
+					t.Errorf("No info for format %s", entry.Name)
+					continue
+				}
+				if info.Name != strings.ToUpper(entry.Name) || info.Planes < 1 {
+					t.Errorf("Unexpected info for format %s: %+v", entry.Name, info)
+				}
+				n++
+			}
+		}
+	}
+	if n != len(formatInfos) {
+		t.Errorf("%d formats in the protocol, %d described", n, len(formatInfos))
+	}
+	for format, p := range packings {
+		if info, _ := format.Info(); info.Planes != 1 || info.Blocks[0].Bytes != p.bytes || info.Alpha != (p.a.bits > 0) || info.YUV {
+			t.Errorf("Info of %s does not match its packing: %+v", format, info)
+		}
+	}
+}
+
+func TestFormatInfoLayout(t *testing.T) {
+	nv12, _ := ShmFormatNv12.Info()
+	if nv12.MinStride(0, 11) != 11 || nv12.MinStride(1, 11) != 12 || nv12.BytesPerPixel(1) != 2 {
+		t.Errorf("Unexpected NV12 layout %+v", nv12)
+	}
+	c1, _ := ShmFormatC1.Info()
+	if c1.MinStride(0, 9) != 2 || c1.BytesPerPixel(0) != 0.125 {
+		t.Errorf("Unexpected C1 layout %+v", c1)
+	}
+	if yuyv, _ := ShmFormatYuyv.Info(); yuyv.MinStride(0, 3) != 8 || yuyv.BytesPerPixel(0) != 2 {
+		t.Errorf("Unexpected YUYV layout %+v", yuyv)
+	}
+	if y210, _ := ShmFormatY210.Info(); y210.MinStride(0, 3) != 16 {
+		t.Errorf("Unexpected Y210 layout %+v", y210)
+	}
+	if argb, _ := ShmFormatArgb8888.Info(); argb.Name != "ARGB8888" || !argb.Alpha || argb.MinStride(0, 10) != 40 {
+		t.Errorf("Unexpected ARGB8888 layout %+v", argb)
+	}
+	if _, err := NewFrame(make([]byte, 4*4+2*2*2-1), 4, 4, ShmFormatNv12); err == nil {
+		t.Error("Frame larger than the data created")
+	}
+	if _, err := NewFrame(make([]byte, 100), 4, 4, ShmFormatYuv4208bit); err == nil {
+		t.Error("Frame of compressed format created")
+	}
+}
+
+func newTestFrame(t *testing.T, format ShmFormat, w, h int) *Frame {
+	t.Helper()
+	f, err := NewFrame(make([]byte, w*h*8), w, h, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// randomFrame returns an opaque ARGB8888 frame with the same color in all
+// pixels of each 2x2 block, so that chroma subsampling loses nothing.
+func randomFrame(t *testing.T, w, h int) *Frame {
+	rnd := rand.New(rand.NewSource(1))
+	f := newTestFrame(t, ShmFormatArgb8888, w, h)
+	for y := 0; y < h; y += 2 {
+		for x := 0; x < w; x += 2 {
+			c := []byte{byte(rnd.Intn(256)), byte(rnd.Intn(256)), byte(rnd.Intn(256)), 0xff}
+			for i := 0; i < 4; i++ {
+				copy(f.Planes[0][(y+i/2)*f.Strides[0]+(x+i%2)*4:], c)
+			}
+		}
+	}
+	return f
+}
+
+func TestConvertPacked(t *testing.T) {
+	src := randomFrame(t, 6, 4)
+	for format := range packings {
+		dst, back := newTestFrame(t, format, 6, 4), newTestFrame(t, ShmFormatArgb8888, 6, 4)
+		if err := Convert(dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if err := Convert(back, dst); err != nil {
+			t.Fatal(err)
+		}
+		// 8 bit formats convert back exactly, others match the image
+		if p := packings[format]; p.r.bits >= 8 && p.g.bits >= 8 && p.b.bits >= 8 {
+			if !bytes.Equal(back.Planes[0], src.Planes[0]) {
+				t.Errorf("%s: pixels changed by conversion", format)
+			}
+			continue
+		}
+		m, _ := NewImage(dst.Planes[0], 6, 4, dst.Strides[0], format)
+		orig, _ := NewImage(src.Planes[0], 6, 4, src.Strides[0], ShmFormatArgb8888)
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 6; x++ {
+				if m.At(x, y) != m.ColorModel().Convert(orig.At(x, y)) {
+					t.Errorf("%s: pixel %d,%d converted to %v", format, x, y, m.At(x, y))
+				}
+			}
+		}
+	}
+	if err := Convert(newTestFrame(t, ShmFormatArgb8888, 4, 4), src); err == nil {
+		t.Error("Frames of different size converted")
+	}
+}
+
+func TestConvertYUV(t *testing.T) {
+	tests := []struct {
+		r, g, b int32
+		y, u, v int32
+	}{
+		{0, 0, 0, 16, 128, 128},
+		{255, 255, 255, 235, 128, 128},
+		{255, 0, 0, 82, 90, 240},
+		{0, 255, 0, 144, 54, 34},
+		{0, 0, 255, 41, 240, 110},
+	}
+	for _, test := range tests {
+		if y, u, v := rgbToYUV(test.r, test.g, test.b); y != test.y || u != test.u || v != test.v {
+			t.Errorf("RGB %d,%d,%d converted to YUV %d,%d,%d", test.r, test.g, test.b, y, u, v)
+		}
+		if r, g, b := yuvToRGB(test.y, test.u, test.v); abs(r-test.r) > 2 || abs(g-test.g) > 2 || abs(b-test.b) > 2 {
+			t.Errorf("YUV %d,%d,%d converted to RGB %d,%d,%d", test.y, test.u, test.v, r, g, b)
+		}
+	}
+
+	src := randomFrame(t, 6, 4)
+	var reference []byte
+	for format := range yuvLayouts {
+		dst, back := newTestFrame(t, format, 6, 4), newTestFrame(t, ShmFormatArgb8888, 6, 4)
+		if err := Convert(dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if err := Convert(back, dst); err != nil {
+			t.Fatal(err)
+		}
+		for i, c := range back.Planes[0] {
+			if d := int32(c) - int32(src.Planes[0][i]); d > 3 || d < -3 {
+				t.Errorf("%s: byte %d converted from %d to %d", format, i, src.Planes[0][i], c)
+				break
+			}
+		}
+		// all layouts carry the same samples
+		if reference == nil {
+			reference = back.Planes[0]
+		} else if !bytes.Equal(back.Planes[0], reference) {
+			t.Errorf("%s: conversion differs from other YUV formats", format)
+		}
+	}
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func TestConvertOddSize(t *testing.T) {
+	src := newTestFrame(t, ShmFormatArgb8888, 5, 3)
+	for i := 0; i < len(src.Planes[0]); i += 4 {
+		copy(src.Planes[0][i:], []byte{40, 120, 200, 0xff})
+	}
+	for format := range yuvLayouts {
+		dst, back := newTestFrame(t, format, 5, 3), newTestFrame(t, ShmFormatArgb8888, 5, 3)
+		if err := Convert(dst, src); err != nil {
+			t.Fatal(err)
+		}
+		if err := Convert(back, dst); err != nil {
+			t.Fatal(err)
+		}
+		for i, c := range back.Planes[0] {
+			if d := int32(c) - int32(src.Planes[0][i]); d > 3 || d < -3 {
+				t.Errorf("%s: byte %d converted from %d to %d", format, i, src.Planes[0][i], c)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package wayland
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Global is an object advertised by wl_registry.global.
+type Global struct {
+	Name      uint32
+	Interface string
+	Version   uint32
+}
+
+// Globals keeps track of the globals of a registry, so that clients do not
+// need their own loop over GlobalChan and GlobalRemoveChan.
+type Globals struct {
+	mu       sync.Mutex
+	display  *Display
+	registry *Registry
+	globals  map[uint32]Global
+	changed  chan struct{}
+	barrier  chan struct{}
+}
+
+// NewGlobals creates a registry and records its globals until the
+// connection ends. Globals present at startup are known after a roundtrip,
+// see Require.
+func NewGlobals(display *Display) (*Globals, error) {
+	c := display.Connection()
+	g := &Globals{
+		display:  display,
+		registry: NewRegistry(c),
+		globals:  make(map[uint32]Global),
+		changed:  make(chan struct{}),
+		barrier:  make(chan struct{}),
+	}
+	// events must reach run in order, so the policy is set before the
+	// server learns about the registry
+	c.SetQueuePolicy(g.registry, QueueBlock, 0)
+	if err := display.GetRegistryWith(g.registry); err != nil {
+		return nil, err
+	}
+	go g.run()
+	return g, nil
+}
+
+// GetRegistryWith is GetRegistry for a registry created by NewRegistry,
+// e.g. to set its queue policy before the server sends the globals.
+func (p *Display) GetRegistryWith(registry *Registry) error {
+	opcode, _ := p.Interface().RequestOpcode("get_registry")
+	return p.Connection().SendRequest(p, opcode, Proxy(registry))
+}
+
+func (g *Globals) run() {
+	c := g.registry.Connection()
+	for {
+		select {
+		case ev := <-g.registry.GlobalChan:
+			g.update(func() {
+				g.globals[ev.Name] = Global{ev.Name, ev.Ifc, ev.Version}
+			})
+		case ev := <-g.registry.GlobalRemoveChan:
+			g.update(func() {
+				delete(g.globals, ev.Name)
+			})
+		case <-g.barrier:
+		case <-c.Done():
+			return
+		}
+	}
+}
+
+// update changes the globals and wakes up waiters.
+func (g *Globals) update(change func()) {
+	g.mu.Lock()
+	change()
+	close(g.changed)
+	g.changed = make(chan struct{})
+	g.mu.Unlock()
+}
+
+// sync returns once run recorded all events the registry received so far.
+func (g *Globals) sync(ctx context.Context) error {
+	c := g.registry.Connection()
+	select {
+	case g.barrier <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.Done():
+		return c.Err()
+	}
+}
+
+func (g *Globals) Registry() *Registry {
+	return g.registry
+}
+
+// All returns the current globals ordered by name.
+func (g *Globals) All() []Global {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	all := make([]Global, 0, len(g.globals))
+	for _, global := range g.globals {
+		all = append(all, global)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Find returns the current globals with the interface, ordered by name.
+func (g *Globals) Find(ifc string) []Global {
+	var found []Global
+	for _, global := range g.All() {
+		if global.Interface == ifc {
+			found = append(found, global)
+		}
+	}
+	return found
+}
+
+// Missing returns the interfaces without a global.
+func (g *Globals) Missing(ifcs ...string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var missing []string
+next:
+	for _, ifc := range ifcs {
+		for _, global := range g.globals {
+			if global.Interface == ifc {
+				continue next
+			}
+		}
+		missing = append(missing, ifc)
+	}
+	return missing
+}
+
+// Require makes a roundtrip, so that all globals of the server are known,
+// and returns a *MissingGlobalsError if some interfaces are not among them.
+func (g *Globals) Require(ctx context.Context, ifcs ...string) error {
+	if err := g.display.Roundtrip(ctx); err != nil {
+		return err
+	}
+	if err := g.sync(ctx); err != nil {
+		return err
+	}
+	if missing := g.Missing(ifcs...); len(missing) > 0 {
+		return &MissingGlobalsError{Interfaces: missing}
+	}
+	return nil
+}
+
+// Wait blocks until globals of all interfaces are advertised. If the
+// context ends first, it returns a *MissingGlobalsError wrapping the
+// context error.
+func (g *Globals) Wait(ctx context.Context, ifcs ...string) error {
+	c := g.registry.Connection()
+	if err := c.Flush(); err != nil {
+		return err
+	}
+	for {
+		g.mu.Lock()
+		changed := g.changed
+		g.mu.Unlock()
+		missing := g.Missing(ifcs...)
+		if len(missing) == 0 {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return &MissingGlobalsError{Interfaces: missing, Err: ctx.Err()}
+		case <-c.Done():
+			return c.Err()
+		case c.Dispatch() <- true:
+		}
+	}
+}
+
+// BindAs binds the first global with the interface of the proxies returned
+// by newProxy, for example NewShm. The version is the advertised one,
+// limited to max and to the version of the generated interface, and must
+// be at least min.
+func BindAs[T Proxy](g *Globals, newProxy func(*Connection) T, min, max uint32) (T, error) {
+	proxy := newProxy(g.registry.Connection())
+	ifc := proxy.Interface().Name
+	found := g.Find(ifc)
+	if len(found) == 0 {
+		var zero T
+		return zero, &MissingGlobalsError{Interfaces: []string{ifc}}
+	}
+	return bindGlobal(g, proxy, found[0], min, max)
+}
+
+// BindGlobal is BindAs for a particular global, for interfaces like
+// wl_output with several globals.
+func BindGlobal[T Proxy](g *Globals, global Global, newProxy func(*Connection) T, min, max uint32) (T, error) {
+	proxy := newProxy(g.registry.Connection())
+	if ifc := proxy.Interface().Name; ifc != global.Interface {
+		var zero T
+		return zero, fmt.Errorf("Global %d is a %s, not a %s.", global.Name, global.Interface, ifc)
+	}
+	return bindGlobal(g, proxy, global, min, max)
+}
+
+func bindGlobal[T Proxy](g *Globals, proxy T, global Global, min, max uint32) (T, error) {
+	var zero T
+	version := global.Version
+	if version > max {
+		version = max
+	}
+	if v := proxy.Interface().Version; version > v {
+		version = v
+	}
+	if version < min {
+		return zero, fmt.Errorf("Global %s %d supports version %d, at least %d is required.", global.Interface, global.Name, version, min)
+	}
+	if err := g.registry.Bind(global.Name, global.Interface, version, proxy); err != nil {
+		return zero, err
+	}
+	return proxy, nil
+}
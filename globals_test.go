@@ -0,0 +1,173 @@
+package wayland
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type bindRequest struct {
+	name    uint32
+	ifc     string
+	version uint32
+}
+
+// registryServer advertises globals on wl_display.get_registry, answers
+// wl_display.sync and reports wl_registry.bind requests. The registry is
+// expected to be the first object created by the client.
+type registryServer struct {
+	mu       sync.Mutex
+	conn     *net.UnixConn
+	registry ProxyId
+	binds    chan bindRequest
+}
+
+func (s *registryServer) send(msgs ...*Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, msg := range msgs {
+		SendWaylandMessage(s.conn, msg)
+	}
+}
+
+func (s *registryServer) global(name uint32, ifc string, version uint32) *Message {
+	return newEvent(s.registry, 0, name, ifc, version)
+}
+
+func (s *registryServer) serve(globals []*Message) {
+	for {
+		msg, err := ReadWaylandMessage(s.conn)
+		if err != nil {
+			return
+		}
+		switch {
+		case msg.Id == 1 && msg.Opcode == 0:
+			id, _ := msg.GetUint32()
+			s.send(newEvent(ProxyId(id), 0, uint32(0)), newEvent(1, 1, id))
+		case msg.Id == 1 && msg.Opcode == 1:
+			s.send(globals...)
+		case msg.Id == s.registry && msg.Opcode == 0:
+			var b bindRequest
+			b.name, _ = msg.GetUint32()
+			b.ifc, _ = msg.GetString()
+			b.version, _ = msg.GetUint32()
+			s.binds <- b
+		}
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	display, conn := newTestDisplay(t)
+	defer display.Connection().Close()
+	s := &registryServer{conn: conn, registry: 2, binds: make(chan bindRequest, 10)}
+	go s.serve([]*Message{
+		s.global(1, "wl_compositor", 6),
+		s.global(2, "wl_shm", 1),
+		s.global(3, "wl_output", 4),
+		s.global(4, "wl_output", 3),
+	})
+	globals, err := NewGlobals(display)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := globals.Require(testContext(t), "wl_compositor", "wl_shm"); err != nil {
+		t.Fatal(err)
+	}
+	var merr *MissingGlobalsError
+	if err := globals.Require(testContext(t), "wl_seat", "wl_shm", "xdg_wm_base"); !errors.As(err, &merr) {
+		t.Fatalf("Unexpected error for missing globals: %v", err)
+	}
+	if !reflect.DeepEqual(merr.Interfaces, []string{"wl_seat", "xdg_wm_base"}) {
+		t.Errorf("Unexpected missing globals %v", merr.Interfaces)
+	}
+
+	compositor, err := BindAs(globals, NewCompositor, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := compositor.Version(); v != 4 {
+		t.Errorf("Compositor bound with version %d, expected 4", v)
+	}
+	if b := <-s.binds; b != (bindRequest{1, "wl_compositor", 4}) {
+		t.Errorf("Unexpected bind request %+v", b)
+	}
+	if _, err := BindAs(globals, NewShm, 2, 2); err == nil {
+		t.Error("Global bound below the minimum version")
+	}
+	if _, err := BindAs(globals, NewSeat, 1, 9); !errors.As(err, &merr) {
+		t.Errorf("Unexpected error for missing global: %v", err)
+	}
+
+	outputs := globals.Find("wl_output")
+	if len(outputs) != 2 {
+		t.Fatalf("Found %d outputs", len(outputs))
+	}
+	output, err := BindGlobal(globals, outputs[1], NewOutput, 2, outputInterface.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := <-s.binds; b != (bindRequest{4, "wl_output", 3}) || output.Version() != 3 {
+		t.Errorf("Unexpected bind request %+v", b)
+	}
+	if _, err := BindGlobal(globals, outputs[0], NewShm, 1, 1); err == nil {
+		t.Error("Output global bound as wl_shm")
+	}
+
+	s.send(newEvent(s.registry, 1, uint32(3)))
+	if err := globals.Require(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+	if all := globals.All(); len(all) != 3 || all[2] != (Global{4, "wl_output", 3}) {
+		t.Errorf("Unexpected globals after removal %v", all)
+	}
+}
+
+func TestGlobalsWait(t *testing.T) {
+	display, conn := newTestDisplay(t)
+	defer display.Connection().Close()
+	s := &registryServer{conn: conn, registry: 2, binds: make(chan bindRequest, 10)}
+	go s.serve(nil)
+	globals, err := NewGlobals(display)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.send(s.global(1, "wl_seat", 9))
+	}()
+	if err := globals.Wait(testContext(t), "wl_seat"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var merr *MissingGlobalsError
+	err = globals.Wait(ctx, "wl_seat", "wl_shm")
+	if !errors.As(err, &merr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Unexpected error for missing global: %v", err)
+	}
+	if !reflect.DeepEqual(merr.Interfaces, []string{"wl_shm"}) {
+		t.Errorf("Unexpected missing globals %v", merr.Interfaces)
+	}
+}
+
+func TestGetRegistryWith(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	requests := readRequests(server)
+	registry := NewRegistry(display.Connection())
+	if err := display.GetRegistryWith(registry); err != nil {
+		t.Fatal(err)
+	}
+	msg := expectRequest(t, requests, 1, 1)
+	if id, _ := msg.GetUint32(); ProxyId(id) != registry.Id() || registry.Id() == 0 {
+		t.Errorf("Registry %d sent as %d", registry.Id(), id)
+	}
+	if _, ok := shmInterface.RequestOpcode("get_registry"); ok {
+		t.Error("Request of another interface found")
+	}
+}
@@ -0,0 +1,295 @@
+package wayland
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// channel is a color component of a packed pixel.
+type channel struct {
+	shift, bits uint
+}
+
+func (c channel) max() uint64 {
+	return 1<<c.bits - 1
+}
+
+// get returns the component of the pixel w scaled to 16 bits.
+func (c channel) get(w uint64) uint32 {
+	return uint32((w >> c.shift & c.max()) * 0xffff / c.max())
+}
+
+// put returns the 16 bit value v placed in the pixel.
+func (c channel) put(v uint32) uint64 {
+	return (uint64(v)*c.max() + 0x7fff) / 0xffff << c.shift
+}
+
+// packing describes a format with all components of a pixel in one little
+// endian word. Alpha has zero bits for formats without alpha.
+type packing struct {
+	bytes      int
+	r, g, b, a channel
+}
+
+// pack returns the packing of a format from its components, most
+// significant first, as in the format names. x marks unused bits.
+func pack(order string, bits ...uint) *packing {
+	p := &packing{}
+	var shift uint
+	for i := len(order) - 1; i >= 0; i-- {
+		c := channel{shift, bits[i]}
+		switch order[i] {
+		case 'r':
+			p.r = c
+		case 'g':
+			p.g = c
+		case 'b':
+			p.b = c
+		case 'a':
+			p.a = c
+		}
+		shift += bits[i]
+	}
+	p.bytes = int(shift / 8)
+	return p
+}
+
+var packings = map[ShmFormat]*packing{
+	ShmFormatRgb332:       pack("rgb", 3, 3, 2),
+	ShmFormatBgr233:       pack("bgr", 2, 3, 3),
+	ShmFormatXrgb4444:     pack("xrgb", 4, 4, 4, 4),
+	ShmFormatXbgr4444:     pack("xbgr", 4, 4, 4, 4),
+	ShmFormatRgbx4444:     pack("rgbx", 4, 4, 4, 4),
+	ShmFormatBgrx4444:     pack("bgrx", 4, 4, 4, 4),
+	ShmFormatArgb4444:     pack("argb", 4, 4, 4, 4),
+	ShmFormatAbgr4444:     pack("abgr", 4, 4, 4, 4),
+	ShmFormatRgba4444:     pack("rgba", 4, 4, 4, 4),
+	ShmFormatBgra4444:     pack("bgra", 4, 4, 4, 4),
+	ShmFormatXrgb1555:     pack("xrgb", 1, 5, 5, 5),
+	ShmFormatXbgr1555:     pack("xbgr", 1, 5, 5, 5),
+	ShmFormatRgbx5551:     pack("rgbx", 5, 5, 5, 1),
+	ShmFormatBgrx5551:     pack("bgrx", 5, 5, 5, 1),
+	ShmFormatArgb1555:     pack("argb", 1, 5, 5, 5),
+	ShmFormatAbgr1555:     pack("abgr", 1, 5, 5, 5),
+	ShmFormatRgba5551:     pack("rgba", 5, 5, 5, 1),
+	ShmFormatBgra5551:     pack("bgra", 5, 5, 5, 1),
+	ShmFormatRgb565:       pack("rgb", 5, 6, 5),
+	ShmFormatBgr565:       pack("bgr", 5, 6, 5),
+	ShmFormatRgb888:       pack("rgb", 8, 8, 8),
+	ShmFormatBgr888:       pack("bgr", 8, 8, 8),
+	ShmFormatArgb8888:     pack("argb", 8, 8, 8, 8),
+	ShmFormatXrgb8888:     pack("xrgb", 8, 8, 8, 8),
+	ShmFormatAbgr8888:     pack("abgr", 8, 8, 8, 8),
+	ShmFormatXbgr8888:     pack("xbgr", 8, 8, 8, 8),
+	ShmFormatRgba8888:     pack("rgba", 8, 8, 8, 8),
+	ShmFormatRgbx8888:     pack("rgbx", 8, 8, 8, 8),
+	ShmFormatBgra8888:     pack("bgra", 8, 8, 8, 8),
+	ShmFormatBgrx8888:     pack("bgrx", 8, 8, 8, 8),
+	ShmFormatXrgb2101010:  pack("xrgb", 2, 10, 10, 10),
+	ShmFormatXbgr2101010:  pack("xbgr", 2, 10, 10, 10),
+	ShmFormatRgbx1010102:  pack("rgbx", 10, 10, 10, 2),
+	ShmFormatBgrx1010102:  pack("bgrx", 10, 10, 10, 2),
+	ShmFormatArgb2101010:  pack("argb", 2, 10, 10, 10),
+	ShmFormatAbgr2101010:  pack("abgr", 2, 10, 10, 10),
+	ShmFormatRgba1010102:  pack("rgba", 10, 10, 10, 2),
+	ShmFormatBgra1010102:  pack("bgra", 10, 10, 10, 2),
+	ShmFormatXrgb16161616: pack("xrgb", 16, 16, 16, 16),
+	ShmFormatXbgr16161616: pack("xbgr", 16, 16, 16, 16),
+	ShmFormatArgb16161616: pack("argb", 16, 16, 16, 16),
+	ShmFormatAbgr16161616: pack("abgr", 16, 16, 16, 16),
+}
+
+func (p *packing) load(pix []byte) uint64 {
+	var w uint64
+	for i := p.bytes - 1; i >= 0; i-- {
+		w = w<<8 | uint64(pix[i])
+	}
+	return w
+}
+
+func (p *packing) store(pix []byte, w uint64) {
+	for i := 0; i < p.bytes; i++ {
+		pix[i] = byte(w)
+		w >>= 8
+	}
+}
+
+func (p *packing) decode(w uint64) color.RGBA64 {
+	c := color.RGBA64{uint16(p.r.get(w)), uint16(p.g.get(w)), uint16(p.b.get(w)), 0xffff}
+	if p.a.bits > 0 {
+		c.A = uint16(p.a.get(w))
+	}
+	return c
+}
+
+func (p *packing) encode(r, g, b, a uint32) uint64 {
+	w := p.r.put(r) | p.g.put(g) | p.b.put(b)
+	if p.a.bits > 0 {
+		w |= p.a.put(a)
+	}
+	return w
+}
+
+// Image is a draw.Image over pixels in one of the packed RGB formats of
+// wl_shm. Like the compositor, it treats color values as premultiplied by
+// alpha. Formats without alpha drop it.
+//
+// Image implements draw.RGBA64Image, so draw.Draw and draw.DrawMask avoid
+// color.Color allocations, but still read, blend and pack every pixel
+// through RGBA64At and SetRGBA64 one at a time. Call the Draw method of
+// the image instead to copy *image.RGBA sources row by row.
+type Image struct {
+	Pix    []byte
+	Stride int
+	Rect   image.Rectangle
+	Format ShmFormat
+	p      *packing
+}
+
+// NewImage returns an image over pix, which holds height rows of stride
+// bytes each.
+func NewImage(pix []byte, width, height, stride int, format ShmFormat) (*Image, error) {
+	p, ok := packings[format]
+	if !ok {
+		return nil, fmt.Errorf("Format %s is not a packed RGB format.", format)
+	}
+	if width < 0 || height < 0 || stride < width*p.bytes || (height > 0 && len(pix) < (height-1)*stride+width*p.bytes) {
+		return nil, fmt.Errorf("Invalid image size %dx%d with stride %d for %d bytes.", width, height, stride, len(pix))
+	}
+	return &Image{Pix: pix, Stride: stride, Rect: image.Rect(0, 0, width, height), Format: format, p: p}, nil
+}
+
+// Image returns an image over the pixels of the buffer. Like Data, it is
+// valid until the buffer is destroyed. Draw into it with Image.Draw rather
+// than draw.Draw for speed.
+func (b *ShmBuffer) Image() (*Image, error) {
+	return NewImage(b.Data, int(b.Width), int(b.Height), int(b.Stride), b.Format)
+}
+
+func (m *Image) ColorModel() color.Model {
+	return color.ModelFunc(func(c color.Color) color.Color {
+		r, g, b, a := c.RGBA()
+		return m.p.decode(m.p.encode(r, g, b, a))
+	})
+}
+
+func (m *Image) Bounds() image.Rectangle {
+	return m.Rect
+}
+
+// PixOffset returns the index of the first byte of the pixel at (x, y).
+func (m *Image) PixOffset(x, y int) int {
+	return (y-m.Rect.Min.Y)*m.Stride + (x-m.Rect.Min.X)*m.p.bytes
+}
+
+func (m *Image) At(x, y int) color.Color {
+	return m.RGBA64At(x, y)
+}
+
+func (m *Image) RGBA64At(x, y int) color.RGBA64 {
+	if !(image.Point{x, y}.In(m.Rect)) {
+		return color.RGBA64{}
+	}
+	return m.p.decode(m.p.load(m.Pix[m.PixOffset(x, y):]))
+}
+
+func (m *Image) Set(x, y int, c color.Color) {
+	if !(image.Point{x, y}.In(m.Rect)) {
+		return
+	}
+	r, g, b, a := c.RGBA()
+	m.p.store(m.Pix[m.PixOffset(x, y):], m.p.encode(r, g, b, a))
+}
+
+func (m *Image) SetRGBA64(x, y int, c color.RGBA64) {
+	if !(image.Point{x, y}.In(m.Rect)) {
+		return
+	}
+	m.p.store(m.Pix[m.PixOffset(x, y):], m.p.encode(uint32(c.R), uint32(c.G), uint32(c.B), uint32(c.A)))
+}
+
+// SubImage returns the part of the image visible through r, sharing the
+// pixels.
+func (m *Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(m.Rect)
+	if r.Empty() {
+		return &Image{Format: m.Format, p: m.p}
+	}
+	return &Image{Pix: m.Pix[m.PixOffset(r.Min.X, r.Min.Y):], Stride: m.Stride, Rect: r, Format: m.Format, p: m.p}
+}
+
+// Draw does the same as draw.Draw(m, r, src, sp, op). Sources of type
+// *image.RGBA are converted a row at a time, where draw.Draw goes through
+// RGBA64At and SetRGBA64 for every pixel of an Image.
+func (m *Image) Draw(r image.Rectangle, src image.Image, sp image.Point, op draw.Op) {
+	s, ok := src.(*image.RGBA)
+	if !ok {
+		draw.Draw(m, r, src, sp, op)
+		return
+	}
+	// clip like draw.Draw
+	orig := r.Min
+	r = r.Intersect(m.Rect)
+	r = r.Intersect(s.Rect.Add(orig.Sub(sp)))
+	if r.Empty() {
+		return
+	}
+	sp = sp.Add(r.Min.Sub(orig))
+	w := r.Dx()
+	for y := 0; y < r.Dy(); y++ {
+		dst := m.Pix[m.PixOffset(r.Min.X, r.Min.Y+y):]
+		row := s.Pix[s.PixOffset(sp.X, sp.Y+y):]
+		if op == draw.Src {
+			m.copyRow(dst, row[:w*4])
+		} else {
+			m.overRow(dst, row[:w*4])
+		}
+	}
+}
+
+// copyRow converts a row of RGBA pixels, with byte shuffling for the
+// common 32 bit formats.
+func (m *Image) copyRow(dst, src []byte) {
+	switch m.Format {
+	case ShmFormatAbgr8888:
+		// the byte order of image.RGBA
+		copy(dst, src)
+	case ShmFormatXbgr8888:
+		for i := 0; i < len(src); i += 4 {
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = src[i], src[i+1], src[i+2], 0
+		}
+	case ShmFormatArgb8888:
+		for i := 0; i < len(src); i += 4 {
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = src[i+2], src[i+1], src[i], src[i+3]
+		}
+	case ShmFormatXrgb8888:
+		for i := 0; i < len(src); i += 4 {
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = src[i+2], src[i+1], src[i], 0
+		}
+	default:
+		n := m.p.bytes
+		for i, j := 0, 0; i < len(src); i, j = i+4, j+n {
+			m.p.store(dst[j:], m.p.encode(uint32(src[i])*0x101, uint32(src[i+1])*0x101, uint32(src[i+2])*0x101, uint32(src[i+3])*0x101))
+		}
+	}
+}
+
+// overRow composites a row of RGBA pixels onto the destination.
+func (m *Image) overRow(dst, src []byte) {
+	n := m.p.bytes
+	for i, j := 0, 0; i < len(src); i, j = i+4, j+n {
+		sa := uint32(src[i+3]) * 0x101
+		r, g, b := uint32(src[i])*0x101, uint32(src[i+1])*0x101, uint32(src[i+2])*0x101
+		if sa != 0xffff {
+			d := m.p.decode(m.p.load(dst[j:]))
+			k := 0xffff - sa
+			r += uint32(d.R) * k / 0xffff
+			g += uint32(d.G) * k / 0xffff
+			b += uint32(d.B) * k / 0xffff
+			sa += uint32(d.A) * k / 0xffff
+		}
+		m.p.store(dst[j:], m.p.encode(r, g, b, sa))
+	}
+}
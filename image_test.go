@@ -0,0 +1,156 @@
+package wayland
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+)
+
+func newTestImage(t *testing.T, format ShmFormat, w, h int) *Image {
+	t.Helper()
+	p := packings[format]
+	m, err := NewImage(make([]byte, w*h*p.bytes), w, h, w*p.bytes, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestImagePixelLayout(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	tests := []struct {
+		format ShmFormat
+		c      color.Color
+		pixel  uint64
+	}{
+		{ShmFormatArgb8888, red, 0xffff0000},
+		{ShmFormatXrgb8888, red, 0x00ff0000},
+		{ShmFormatAbgr8888, red, 0xff0000ff},
+		{ShmFormatRgba8888, color.RGBA{0, 0, 0x80, 0x80}, 0x00008080},
+		{ShmFormatRgb565, red, 0xf800},
+		{ShmFormatBgr565, red, 0x001f},
+		{ShmFormatRgb888, red, 0xff0000},
+		{ShmFormatXrgb2101010, color.White, 0x3fffffff},
+		{ShmFormatArgb2101010, color.Transparent, 0},
+		{ShmFormatArgb1555, color.RGBA{0, 0xff, 0, 0xff}, 0x83e0},
+		{ShmFormatRgb332, red, 0xe0},
+		{ShmFormatAbgr16161616, red, 0xffff00000000ffff},
+	}
+	for _, test := range tests {
+		m := newTestImage(t, test.format, 1, 1)
+		m.Set(0, 0, test.c)
+		var want [8]byte
+		binary.LittleEndian.PutUint64(want[:], test.pixel)
+		if !bytes.Equal(m.Pix, want[:len(m.Pix)]) {
+			t.Errorf("%s: %v stored as %x, expected %x", test.format, test.c, m.Pix, want[:len(m.Pix)])
+		}
+		if got := m.At(0, 0); got != m.ColorModel().Convert(test.c) {
+			t.Errorf("%s: %v read back as %v", test.format, test.c, got)
+		}
+	}
+}
+
+func TestImageFormats(t *testing.T) {
+	colors := []color.Color{color.Black, color.White, color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0xff, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}}
+	for format, p := range packings {
+		m := newTestImage(t, format, 3, 2)
+		for _, c := range colors {
+			m.Set(2, 1, c)
+			if r, g, b, _ := m.At(2, 1).RGBA(); color.RGBA64Model.Convert(c) != (color.RGBA64{uint16(r), uint16(g), uint16(b), 0xffff}) {
+				t.Errorf("%s: %v read back as %v", format, c, m.At(2, 1))
+			}
+		}
+		if p.a.bits > 0 {
+			m.Set(0, 0, color.Transparent)
+			if _, _, _, a := m.At(0, 0).RGBA(); a != 0 {
+				t.Errorf("%s: transparent read back as %v", format, m.At(0, 0))
+			}
+		}
+	}
+	if _, err := NewImage(nil, 1, 1, 4, ShmFormatNv12); err == nil {
+		t.Error("Image of planar YUV format created")
+	}
+	if _, err := NewImage(make([]byte, 7), 2, 1, 8, ShmFormatArgb8888); err == nil {
+		t.Error("Image larger than the pixels created")
+	}
+}
+
+func TestImageDrawRGBA(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	src := image.NewRGBA(image.Rect(-2, -2, 14, 10))
+	for i := 0; i < len(src.Pix); i += 4 {
+		a := byte(rnd.Intn(256))
+		switch i % 12 {
+		case 0:
+			a = 0xff
+		case 4:
+			a = 0
+		}
+		for j := 0; j < 3; j++ {
+			src.Pix[i+j] = byte(rnd.Intn(int(a) + 1))
+		}
+		src.Pix[i+3] = a
+	}
+	for format := range packings {
+		for _, op := range []draw.Op{draw.Src, draw.Over} {
+			fast, slow := newTestImage(t, format, 10, 8), newTestImage(t, format, 10, 8)
+			for i := range fast.Pix {
+				fast.Pix[i] = byte(i * 7)
+				slow.Pix[i] = byte(i * 7)
+			}
+			r := image.Rect(1, 1, 20, 20)
+			fast.Draw(r, src, image.Pt(0, -1), op)
+			draw.Draw(slow, r, src, image.Pt(0, -1), op)
+			if !bytes.Equal(fast.Pix, slow.Pix) {
+				for i := range fast.Pix {
+					if fast.Pix[i] != slow.Pix[i] {
+						t.Errorf("%s: Draw with op %d differs from draw.Draw at %d: %d %d", format, op, i, fast.Pix[i], slow.Pix[i])
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestShmBufferImage(t *testing.T) {
+	b := &ShmBuffer{Width: 4, Height: 2, Stride: 20, Format: ShmFormatXrgb8888, Data: make([]byte, 40)}
+	m, err := b.Image()
+	if err != nil {
+		t.Fatal(err)
+	}
+	draw.Draw(m, m.Bounds(), image.NewUniform(color.RGBA{1, 2, 3, 0xff}), image.Point{}, draw.Src)
+	if !bytes.Equal(b.Data[20:24], []byte{3, 2, 1, 0}) || b.Data[16] != 0 {
+		t.Errorf("Unexpected buffer contents %v", b.Data)
+	}
+	sub := m.SubImage(image.Rect(1, 1, 3, 2)).(*Image)
+	sub.Set(1, 1, color.Black)
+	if !bytes.Equal(b.Data[24:28], []byte{0, 0, 0, 0}) {
+		t.Errorf("Sub image does not share the pixels: %v", b.Data)
+	}
+}
+
+func benchmarkImageDraw(b *testing.B, draw func(m *Image, src *image.RGBA)) {
+	src := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	m, _ := NewImage(make([]byte, 256*256*4), 256, 256, 256*4, ShmFormatArgb8888)
+	b.SetBytes(int64(len(src.Pix)))
+	for i := 0; i < b.N; i++ {
+		draw(m, src)
+	}
+}
+
+func BenchmarkImageDraw(b *testing.B) {
+	benchmarkImageDraw(b, func(m *Image, src *image.RGBA) {
+		m.Draw(m.Rect, src, image.Point{}, draw.Src)
+	})
+}
+
+func BenchmarkImageDrawGeneric(b *testing.B) {
+	benchmarkImageDraw(b, func(m *Image, src *image.RGBA) {
+		draw.Draw(m, m.Rect, src, image.Point{}, draw.Src)
+	})
+}
@@ -5,172 +5,230 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strings"
 	"syscall"
 )
 
 type Message struct {
-	Id           ProxyId
-	Opcode       uint32
-	size         uint32
-	data         *bytes.Buffer
-	control      *bytes.Buffer
-	control_msgs []syscall.SocketControlMessage
+	Id      ProxyId
+	Opcode  uint32
+	size    uint32
+	data    *bytes.Buffer
+	sendFds []int
+	fds     *fdQueue
+	// discard marks an event for a zombie, which is decoded only for the
+	// objects it creates. These become zombies too, fds are closed.
+	discard bool
+}
+
+// corrupted wraps a decoding failure into ErrConnectionCorrupted.
+func corrupted(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrConnectionCorrupted, fmt.Sprintf(format, args...))
 }
 
 func ReadWaylandMessage(conn *net.UnixConn) (*Message, error) {
 	var buf [8]byte
-	msg := Message{}
-	control := make([]byte, 24)
+	msg := Message{fds: &fdQueue{}}
+	control := newControlBuffer()
 
-	n, oobn, _, _, err := conn.ReadMsgUnix(buf[:], control)
+	n, oobn, flags, _, err := conn.ReadMsgUnix(buf[:], control)
 	if err != nil {
 		return nil, err
 	}
+	if err = msg.fds.receive(control[:oobn], flags); err != nil {
+		msg.fds.closeAll()
+		return nil, err
+	}
 	if n != 8 {
+		msg.fds.closeAll()
 		return nil, errors.New("Unable to read message header.")
 	}
-	if oobn > 0 {
-		if oobn > len(control) {
-			panic("Unsufficient control msg buffer")
-		}
-		msg.control_msgs, err = syscall.ParseSocketControlMessage(control)
-		if err != nil {
-			panic(fmt.Sprintf("Control message parse error: %s", err.Error()))
-		}
-	}
 
 	msg.Id = ProxyId(binary.LittleEndian.Uint32(buf[0:4]))
 	msg.Opcode = uint32(binary.LittleEndian.Uint16(buf[4:6]))
 	msg.size = uint32(binary.LittleEndian.Uint16(buf[6:8]))
+	if msg.size < 8 || msg.size&0x3 != 0 || msg.size > maxMessageSize {
+		return nil, corrupted("invalid message size %d", msg.size)
+	}
 
 	// subtract 8 bytes from header
 	data := make([]byte, msg.size-8)
 
-	n, err = conn.Read(data)
-	if err != nil {
+	if _, err = io.ReadFull(conn, data); err != nil {
 		return nil, err
 	}
-	if n != int(msg.size)-8 {
-		return nil, errors.New("Invalid message size.")
-	}
 	msg.data = bytes.NewBuffer(data)
 
 	return &msg, nil
 }
 
+// ObjectArg passes an object argument of a request to SendRequest. A nil
+// pointer becomes nil, the null object.
+func ObjectArg[T any, P interface {
+	*T
+	Proxy
+}](p P) interface{} {
+	if p == nil {
+		return nil
+	}
+	return p
+}
+
 func (m *Message) Write(arg interface{}) error {
 	switch t := arg.(type) {
 	case Proxy:
 		return binary.Write(m.data, binary.LittleEndian, uint32(t.Id()))
 	case uint32, int32:
 		return binary.Write(m.data, binary.LittleEndian, t)
-	case float32:
-		f := float64ToFixed(float64(t))
-		return binary.Write(m.data, binary.LittleEndian, f)
+	case Fixed:
+		return binary.Write(m.data, binary.LittleEndian, int32(t))
 	case string:
-		str, _ := arg.(string)
-		tail := 4 - (len(str) & 0x3)
-		err := binary.Write(m.data, binary.LittleEndian, uint32(len(str)+tail))
-		if err != nil {
-			return err
-		}
-		err = binary.Write(m.data, binary.LittleEndian, []byte(str))
-		if err != nil {
-			return err
-		}
-		padding := make([]byte, tail)
-		return binary.Write(m.data, binary.LittleEndian, padding)
+		// the length includes the terminating NUL, but not the padding
+		l := len(t) + 1
+		binary.Write(m.data, binary.LittleEndian, uint32(l))
+		m.data.WriteString(t)
+		m.data.Write(make([]byte, 1+(4-l&0x3)&0x3))
+		return nil
+	case []byte:
+		binary.Write(m.data, binary.LittleEndian, uint32(len(t)))
+		m.data.Write(t)
+		m.data.Write(make([]byte, (4-len(t)&0x3)&0x3))
+		return nil
 	case uintptr:
-		rights := syscall.UnixRights(int(t))
-		return binary.Write(m.control, binary.LittleEndian, rights)
+		m.sendFds = append(m.sendFds, int(t))
+		return nil
 	default:
-		panic("Invalid Wayland request parameter type.")
+		return fmt.Errorf("Invalid Wayland request parameter type %T.", arg)
 	}
-	return nil
 }
 
-func (m *Message) GetProxy(c *Connection) Proxy {
-	buf := m.data.Next(4)
-	if len(buf) != 4 {
-		panic("Unable to read object id")
+// GetProxy returns the registered object for the next argument. A null
+// object or one unregistered meanwhile yields nil.
+func (m *Message) GetProxy(c *Connection) (Proxy, error) {
+	id, err := m.GetUint32()
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 {
+		return nil, nil
+	}
+	c.mu.Lock()
+	proxy, ok := c.objects[ProxyId(id)]
+	c.mu.Unlock()
+	if !ok {
+		return nil, corrupted("unknown object %d", id)
 	}
-	return c.objects[ProxyId(binary.LittleEndian.Uint32(buf))]
+	return proxy, nil
 }
 
-func (m *Message) GetFD() uintptr {
-	if m.control_msgs == nil {
-		return 0
+// GetObject returns the registered object for the next argument, which
+// must be of type T. A null object yields the zero value.
+func GetObject[T Proxy](c *Connection, m *Message) (T, error) {
+	var ret T
+	proxy, err := m.GetProxy(c)
+	if err != nil || proxy == nil {
+		return ret, err
 	}
-	fds, err := syscall.ParseUnixRights(&m.control_msgs[0])
+	ret, ok := proxy.(T)
+	if !ok {
+		return ret, corrupted("unexpected %s object %d", proxy.Interface().Name, proxy.Id())
+	}
+	return ret, nil
+}
+
+// GetNewId registers proxy with the server allocated id of the next
+// argument. The new object inherits the version of parent, the object the
+// event was sent to.
+func (m *Message) GetNewId(parent Proxy, proxy Proxy) error {
+	id, err := m.GetUint32()
 	if err != nil {
-		panic("Unable to parse unix rights")
+		return err
+	}
+	proxy.SetVersion(parent.Version())
+	if err = parent.Connection().RegisterWithId(proxy, ProxyId(id)); err != nil {
+		return corrupted("%s", err)
+	}
+	if m.discard {
+		parent.Connection().Unregister(proxy)
+	}
+	return nil
+}
+
+// GetFD takes the next file descriptor received on the connection.
+func (m *Message) GetFD() (uintptr, error) {
+	if m.fds == nil {
+		return 0, corrupted("missing file descriptor")
+	}
+	fd, ok := m.fds.pop()
+	if !ok {
+		return 0, corrupted("missing file descriptor")
 	}
-	m.control_msgs = append(m.control_msgs[0:], m.control_msgs[1:]...)
-	if len(fds) != 1 {
-		panic("Expected 1 file descriptor, got more")
+	if m.discard {
+		syscall.Close(fd)
 	}
-	return uintptr(fds[0])
+	return uintptr(fd), nil
 }
 
-func (m *Message) GetString() string {
+// GetString returns the next string argument, the empty string for null.
+func (m *Message) GetString() (string, error) {
 	buf := m.data.Next(4)
 	if len(buf) != 4 {
-		panic("Unable to read string length")
+		return "", corrupted("unable to read string length")
 	}
-	l := int(binary.LittleEndian.Uint32(buf))
-	buf = m.data.Next(l)
-	if len(buf) != l {
-		panic("Unable to read string")
+	l := int64(binary.LittleEndian.Uint32(buf))
+	padded := (l + 3) &^ 3
+	if padded > int64(m.data.Len()) {
+		return "", corrupted("string length %d exceeds message", l)
 	}
-	ret := strings.TrimRight(string(buf), "\x00")
-	//padding to 32 bit boundary
-	if (l & 0x3) != 0 {
-		buf = m.data.Next(4 - (l & 0x3))
-	}
-	return ret
+	buf = m.data.Next(int(padded))[:l]
+	return strings.TrimRight(string(buf), "\x00"), nil
 }
 
-func (m *Message) GetInt32() int32 {
+func (m *Message) GetInt32() (int32, error) {
 	buf := m.data.Next(4)
 	if len(buf) != 4 {
-		panic("Unable to read int")
+		return 0, corrupted("unable to read int")
 	}
-	return int32(binary.LittleEndian.Uint32(buf))
+	return int32(binary.LittleEndian.Uint32(buf)), nil
 }
 
-func (m *Message) GetUint32() uint32 {
+func (m *Message) GetUint32() (uint32, error) {
 	buf := m.data.Next(4)
 	if len(buf) != 4 {
-		panic("Unable to read unsigned int")
+		return 0, corrupted("unable to read unsigned int")
 	}
-	return binary.LittleEndian.Uint32(buf)
+	return binary.LittleEndian.Uint32(buf), nil
+}
+
+// GetEnum reads the next argument as enum value of type T.
+func GetEnum[T ~int32 | ~uint32](m *Message) (T, error) {
+	v, err := m.GetUint32()
+	return T(v), err
 }
 
-func (m *Message) GetFloat32() float32 {
+func (m *Message) GetFixed() (Fixed, error) {
 	buf := m.data.Next(4)
 	if len(buf) != 4 {
-		panic("Unable to read fixed")
+		return 0, corrupted("unable to read fixed")
 	}
-	return float32(fixedToFloat64(int32(binary.LittleEndian.Uint32(buf))))
+	return Fixed(binary.LittleEndian.Uint32(buf)), nil
 }
 
-func (m *Message) GetArray() []int32 {
+// GetArray returns the contents of the next array argument. See ArrayOf
+// for arrays of integers.
+func (m *Message) GetArray() ([]byte, error) {
 	buf := m.data.Next(4)
 	if len(buf) != 4 {
-		panic("Unable to array len")
+		return nil, corrupted("unable to read array length")
 	}
-	l := binary.LittleEndian.Uint32(buf)
-	arr := make([]int32, l/4)
-	for _, i := range arr {
-		buf = m.data.Next(4)
-		if len(buf) != 4 {
-			panic("Unable to array element")
-		}
-		arr[i] = int32(binary.LittleEndian.Uint32(buf))
+	l := int64(binary.LittleEndian.Uint32(buf))
+	padded := (l + 3) &^ 3
+	if padded > int64(m.data.Len()) {
+		return nil, corrupted("array length %d exceeds message", l)
 	}
-	return arr
+	return m.data.Next(int(padded))[:l], nil
 }
 
 func NewRequest(p Proxy, opcode uint32) *Message {
@@ -178,24 +236,33 @@ func NewRequest(p Proxy, opcode uint32) *Message {
 	msg.Opcode = opcode
 	msg.Id = p.Id()
 	msg.data = &bytes.Buffer{}
-	msg.control = &bytes.Buffer{}
 
 	return &msg
 }
 
-func SendWaylandMessage(conn *net.UnixConn, m *Message) error {
-	header := &bytes.Buffer{}
+// marshal appends header and arguments of the message to w.
+func (m *Message) marshal(w *bytes.Buffer) {
 	// calculate message total size
 	m.size = uint32(m.data.Len() + 8)
-	binary.Write(header, binary.LittleEndian, m.Id)
-	binary.Write(header, binary.LittleEndian, m.size<<16|m.Opcode&0x0000ffff)
+	binary.Write(w, binary.LittleEndian, m.Id)
+	binary.Write(w, binary.LittleEndian, m.size<<16|m.Opcode&0x0000ffff)
+	w.Write(m.data.Bytes())
+}
 
-	d, c, err := conn.WriteMsgUnix(append(header.Bytes(), m.data.Bytes()...), m.control.Bytes(), nil)
+func SendWaylandMessage(conn *net.UnixConn, m *Message) error {
+	buf := &bytes.Buffer{}
+	m.marshal(buf)
+	var control []byte
+	if len(m.sendFds) > 0 {
+		control = syscall.UnixRights(m.sendFds...)
+	}
+
+	d, c, err := conn.WriteMsgUnix(buf.Bytes(), control, nil)
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
-	if c != m.control.Len() || d != (header.Len()+m.data.Len()) {
-		panic("WriteMsgUnix failed.")
+	if c != len(control) || d != buf.Len() {
+		return errors.New("WriteMsgUnix failed.")
 	}
-	return err
+	return nil
 }
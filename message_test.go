@@ -0,0 +1,152 @@
+package wayland
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestMessage(words ...uint32) *Message {
+	data := &bytes.Buffer{}
+	for _, w := range words {
+		binary.Write(data, binary.LittleEndian, w)
+	}
+	return &Message{data: data}
+}
+
+func TestMessageDecodeErrors(t *testing.T) {
+	ctx := newConnection(nil)
+	tests := map[string]func(m *Message) error{
+		"int": func(m *Message) error {
+			_, err := m.GetInt32()
+			return err
+		},
+		"uint": func(m *Message) error {
+			_, err := m.GetUint32()
+			return err
+		},
+		"fixed": func(m *Message) error {
+			_, err := m.GetFixed()
+			return err
+		},
+		"string": func(m *Message) error {
+			_, err := m.GetString()
+			return err
+		},
+		"array": func(m *Message) error {
+			_, err := m.GetArray()
+			return err
+		},
+		"fd": func(m *Message) error {
+			_, err := m.GetFD()
+			return err
+		},
+		"object": func(m *Message) error {
+			_, err := m.GetProxy(ctx)
+			return err
+		},
+	}
+	for name, get := range tests {
+		if err := get(newTestMessage()); !errors.Is(err, ErrConnectionCorrupted) {
+			t.Errorf("%s: expected ErrConnectionCorrupted on empty message, got %v", name, err)
+		}
+	}
+	if _, err := newTestMessage(1000, 0).GetString(); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("Oversized string accepted: %v", err)
+	}
+	if _, err := newTestMessage(0xfffffff0).GetString(); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("Huge string accepted: %v", err)
+	}
+	if _, err := newTestMessage(5, 0x64636261).GetString(); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("String without padding accepted: %v", err)
+	}
+	if _, err := newTestMessage(0xfffffff0).GetArray(); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("Oversized array accepted: %v", err)
+	}
+	if _, err := newTestMessage(17).GetProxy(ctx); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("Unknown object accepted: %v", err)
+	}
+	if p, err := newTestMessage(0).GetProxy(ctx); p != nil || err != nil {
+		t.Errorf("Null object decoded to %v, %v", p, err)
+	}
+}
+
+func TestMessageWriteInvalidType(t *testing.T) {
+	m := newTestMessage()
+	if err := m.Write(3.5); err == nil {
+		t.Error("float64 argument accepted")
+	}
+	if err := m.Write(nil); err == nil {
+		t.Error("nil argument accepted")
+	}
+}
+
+func TestReadWaylandMessageInvalidSize(t *testing.T) {
+	client, server := socketPair(t)
+	defer client.Close()
+	defer server.Close()
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[0:], 1)
+	binary.LittleEndian.PutUint32(header[4:], 4<<16)
+	server.Write(header[:])
+	if _, err := ReadWaylandMessage(client); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("Expected ErrConnectionCorrupted, got %v", err)
+	}
+}
+
+func TestMalformedEventFailsConnection(t *testing.T) {
+	events := map[string]func(d *Display, o *Output) *Message{
+		"truncated": func(d *Display, o *Output) *Message {
+			return newEvent(o.Id(), 0, int32(1))
+		},
+		"opcode": func(d *Display, o *Output) *Message {
+			return newEvent(o.Id(), 42)
+		},
+		"object type": func(d *Display, o *Output) *Message {
+			// wl_surface.enter carrying the display instead of an output
+			return newEvent(o.Id()+1, 0, Proxy(d))
+		},
+	}
+	for name, event := range events {
+		display, server := newTestDisplay(t)
+		output := NewOutput(display.Connection())
+		display.Connection().register(output)
+		display.Connection().register(NewSurface(display.Connection()))
+		if err := SendWaylandMessage(server, event(display, output)); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-display.Connection().Done():
+		case <-time.After(time.Second):
+			t.Fatalf("%s: connection not terminated", name)
+		}
+		if !errors.Is(display.Connection().Err(), ErrConnectionCorrupted) {
+			t.Errorf("%s: expected ErrConnectionCorrupted, got %v", name, display.Connection().Err())
+		}
+	}
+}
+
+func TestMessageStringEncoding(t *testing.T) {
+	tests := map[string][]byte{
+		"":     {1, 0, 0, 0, 0, 0, 0, 0},
+		"abc":  {4, 0, 0, 0, 'a', 'b', 'c', 0},
+		"abcd": {5, 0, 0, 0, 'a', 'b', 'c', 'd', 0, 0, 0, 0},
+	}
+	for s, wire := range tests {
+		m := newTestMessage()
+		if err := m.Write(s); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m.data.Bytes(), wire) {
+			t.Errorf("%q encoded as %v, expected %v", s, m.data.Bytes(), wire)
+		}
+		if got, err := m.GetString(); got != s || err != nil || m.data.Len() != 0 {
+			t.Errorf("%q decoded as %q, %v", s, got, err)
+		}
+	}
+	if got, err := newTestMessage(0).GetString(); got != "" || err != nil {
+		t.Errorf("Null string decoded as %q, %v", got, err)
+	}
+}
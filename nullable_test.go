@@ -0,0 +1,145 @@
+package wayland
+
+import (
+	"testing"
+	"time"
+)
+
+// nullableArgs lists the nullable arguments of the core protocol as
+// interface.message.argument, requests and events separately.
+func nullableArgs(events bool) map[string]bool {
+	ret := make(map[string]bool)
+	for _, p := range coreProxies(newConnection(nil)) {
+		ifc := p.Interface()
+		methods := ifc.Requests
+		if events {
+			methods = ifc.Events
+		}
+		for _, m := range methods {
+			for _, arg := range m.Args {
+				if arg.Nullable {
+					ret[ifc.Name+"."+m.Name+"."+arg.Name] = true
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// skipArgs consumes the arguments of a received request preceding the
+// argument with index n.
+func skipArgs(t *testing.T, msg *Message, m Method, n int) {
+	for _, arg := range m.Args[:n] {
+		var err error
+		switch arg.Type {
+		case 's':
+			_, err = msg.GetString()
+		case 'a':
+			_, err = msg.GetArray()
+		case 'h':
+		default:
+			_, err = msg.GetUint32()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNullableRequests(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	ctx := display.Connection()
+	newProxy := func(p Proxy) Proxy {
+		p.SetVersion(p.Interface().Version)
+		ctx.register(p)
+		return p
+	}
+	offer := newProxy(NewDataOffer(ctx)).(*DataOffer)
+	device := newProxy(NewDataDevice(ctx)).(*DataDevice)
+	source := newProxy(NewDataSource(ctx)).(*DataSource)
+	origin := newProxy(NewSurface(ctx)).(*Surface)
+	shellSurface := newProxy(NewShellSurface(ctx)).(*ShellSurface)
+	surface := newProxy(NewSurface(ctx)).(*Surface)
+	pointer := newProxy(NewPointer(ctx)).(*Pointer)
+
+	tests := map[string]func() error{
+		"wl_data_offer.accept.mime_type":      func() error { return offer.Accept(1, "") },
+		"wl_data_device.start_drag.source":    func() error { return device.StartDrag(nil, origin, surface, 1) },
+		"wl_data_device.start_drag.icon":      func() error { return device.StartDrag(source, origin, nil, 1) },
+		"wl_data_device.set_selection.source": func() error { return device.SetSelection(nil, 1) },
+		"wl_shell_surface.set_fullscreen.output": func() error {
+			return shellSurface.SetFullscreen(ShellSurfaceFullscreenMethodDefault, 0, nil)
+		},
+		"wl_shell_surface.set_maximized.output": func() error { return shellSurface.SetMaximized(nil) },
+		"wl_surface.attach.buffer":              func() error { return surface.Attach(nil, 0, 0) },
+		"wl_surface.set_opaque_region.region":   func() error { return surface.SetOpaqueRegion(nil) },
+		"wl_surface.set_input_region.region":    func() error { return surface.SetInputRegion(nil) },
+		"wl_pointer.set_cursor.surface":         func() error { return pointer.SetCursor(1, nil, 0, 0) },
+	}
+	for name := range nullableArgs(false) {
+		if tests[name] == nil {
+			t.Errorf("No test for nullable argument %s", name)
+		}
+	}
+	for name, send := range tests {
+		if err := send(); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		server.SetReadDeadline(time.Now().Add(time.Second))
+		msg, err := ReadWaylandMessage(server)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proxy := ctx.objects[msg.Id]
+		m := proxy.Interface().Requests[msg.Opcode]
+		for i, arg := range m.Args {
+			if proxy.Interface().Name+"."+m.Name+"."+arg.Name != name {
+				continue
+			}
+			skipArgs(t, msg, m, i)
+			if v, err := msg.GetUint32(); v != 0 || err != nil {
+				t.Errorf("%s: null sent as %d, %v", name, v, err)
+			}
+		}
+	}
+
+	if err := shellSurface.SetTransient(nil, 0, 0, 0); err == nil {
+		t.Error("Null accepted for wl_shell_surface.set_transient.parent")
+	}
+	if err := ctx.Err(); err != nil {
+		t.Errorf("Rejected request terminated the connection: %s", err)
+	}
+}
+
+func TestNullableEvents(t *testing.T) {
+	ctx := newConnection(nil)
+	source, device, surface := NewDataSource(ctx), NewDataDevice(ctx), NewSurface(ctx)
+	for _, p := range []Proxy{source, device, surface} {
+		ctx.register(p)
+	}
+	tests := map[string]struct {
+		proxy Proxy
+		event *Message
+		check func() bool
+	}{
+		"wl_data_source.target.mime_type": {source, newEvent(source.Id(), 0, uint32(0)),
+			func() bool { return (<-source.TargetChan).MimeType == "" }},
+		"wl_data_device.enter.id": {device, newEvent(device.Id(), 1, uint32(1), Proxy(surface), Fixed(0), Fixed(0), uint32(0)),
+			func() bool { return (<-device.EnterChan).Id == nil }},
+		"wl_data_device.selection.id": {device, newEvent(device.Id(), 5, uint32(0)),
+			func() bool { return (<-device.SelectionChan).Id == nil }},
+	}
+	for name := range nullableArgs(true) {
+		if _, ok := tests[name]; !ok {
+			t.Errorf("No test for nullable argument %s", name)
+		}
+	}
+	for name, test := range tests {
+		go test.proxy.Dispatch(test.event.Opcode, test.event)
+		if !test.check() {
+			t.Errorf("%s: null not decoded as nil", name)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package wayland
+
+import (
+	"log"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// QueuePolicy selects how decoded events are handed to proxy channels.
+type QueuePolicy int
+
+const (
+	// QueueBlock hands every event over synchronously. The connection
+	// waits until the application receives it.
+	QueueBlock QueuePolicy = iota
+	// QueueBuffer keeps up to the queue size events per channel and then
+	// waits for the application.
+	QueueBuffer
+	// QueueDropOldest keeps up to the queue size events per channel and
+	// discards the oldest pending one when full.
+	QueueDropOldest
+	// QueueDropUnsubscribed discards events for channels which were not
+	// passed to Connection.Subscribe. Subscribed channels are buffered.
+	QueueDropUnsubscribed
+)
+
+const (
+	defaultQueueSize    = 32
+	defaultStallTimeout = 5 * time.Second
+)
+
+// Stall describes events piling up for a channel nobody reads.
+type Stall struct {
+	Proxy   Proxy
+	Channel string
+	Pending int
+}
+
+type sendResult int
+
+const (
+	sendDelivered sendResult = iota
+	sendStopped
+	sendStalled
+)
+
+// eventSender delivers one decoded event to its channel. It gives up when
+// stop is closed or, if stall is not nil, when it fires.
+type eventSender func(stop <-chan struct{}, stall <-chan time.Time) sendResult
+
+// chanSender returns an eventSender sending ev on ch.
+func chanSender[E any](ch chan E, ev E) eventSender {
+	return func(stop <-chan struct{}, stall <-chan time.Time) sendResult {
+		// prefer a waiting receiver over a concurrent shutdown
+		select {
+		case ch <- ev:
+			return sendDelivered
+		default:
+		}
+		select {
+		case ch <- ev:
+			return sendDelivered
+		case <-stop:
+			return sendStopped
+		case <-stall:
+			return sendStalled
+		}
+	}
+}
+
+// closeFds closes the file descriptors of an event which is never
+// delivered, the uintptr fields of the generated event structs.
+func closeFds(ev interface{}) {
+	v := reflect.ValueOf(ev)
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.Kind() == reflect.Uintptr {
+			syscall.Close(int(f.Uint()))
+		}
+	}
+}
+
+// queuedEvent is an event waiting in an eventQueue. drop releases its
+// resources if it is never sent.
+type queuedEvent struct {
+	send eventSender
+	drop func()
+}
+
+func (e queuedEvent) discard() {
+	if e.drop != nil {
+		e.drop()
+	}
+}
+
+type queueConfig struct {
+	policy QueuePolicy
+	size   int
+}
+
+type eventQueue struct {
+	proxy   Proxy
+	name    string
+	pending []queuedEvent
+	running bool
+	stalled bool
+	// drops is set for QueueDropOldest, which never holds up the
+	// connection, so a waiting event is no stall
+	drops bool
+	space chan struct{}
+	// stop is closed when the proxy is gone or the connection shuts down
+	stop chan struct{}
+}
+
+type eventQueues struct {
+	mu           sync.Mutex
+	config       queueConfig
+	overrides    map[Proxy]queueConfig
+	queues       map[interface{}]*eventQueue
+	subscribed   map[interface{}]bool
+	stallTimeout time.Duration
+	onStall      func(Stall)
+	stop         chan struct{}
+	closed       bool
+}
+
+func newEventQueues() *eventQueues {
+	return &eventQueues{
+		config:       queueConfig{QueueBlock, defaultQueueSize},
+		overrides:    make(map[Proxy]queueConfig),
+		queues:       make(map[interface{}]*eventQueue),
+		subscribed:   make(map[interface{}]bool),
+		stallTimeout: defaultStallTimeout,
+		stop:         make(chan struct{}),
+	}
+}
+
+// LogStall is a stall handler for WithStallHandler which writes a message
+// with the standard logger.
+func LogStall(s Stall) {
+	log.Printf("wayland: %d events pending on %s of object %d, nobody reads the channel", s.Pending, s.Channel, s.Proxy.Id())
+}
+
+// WithQueuePolicy sets the default event queue policy of the connection.
+func WithQueuePolicy(policy QueuePolicy, size int) Option {
+	return func(c *Connection) {
+		c.events.config = queueConfig{policy, size}
+	}
+}
+
+// WithStallHandler reports events which wait longer than timeout for a
+// reader to handler, e.g. LogStall. Stalls are not reported without it,
+// nor for channels with the QueueDropOldest policy.
+func WithStallHandler(timeout time.Duration, handler func(Stall)) Option {
+	return func(c *Connection) {
+		c.events.stallTimeout = timeout
+		c.events.onStall = handler
+	}
+}
+
+// SetQueuePolicy overrides the queue policy for all channels of a proxy.
+func (context *Connection) SetQueuePolicy(proxy Proxy, policy QueuePolicy, size int) {
+	q := context.events
+	q.mu.Lock()
+	q.overrides[proxy] = queueConfig{policy, size}
+	q.mu.Unlock()
+}
+
+// Subscribe marks an event channel as read by the application, so that
+// QueueDropUnsubscribed delivers its events.
+func (context *Connection) Subscribe(ch interface{}) {
+	q := context.events
+	q.mu.Lock()
+	q.subscribed[ch] = true
+	q.mu.Unlock()
+}
+
+func (context *Connection) Unsubscribe(ch interface{}) {
+	q := context.events
+	q.mu.Lock()
+	delete(q.subscribed, ch)
+	q.mu.Unlock()
+}
+
+// forget drops the per-proxy configuration and the queued events once the
+// proxy is gone.
+func (q *eventQueues) forget(proxy Proxy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.overrides, proxy)
+	for ch, eq := range q.queues {
+		if eq.proxy != proxy {
+			continue
+		}
+		for _, e := range eq.pending {
+			e.discard()
+		}
+		eq.pending = nil
+		delete(q.queues, ch)
+		close(eq.stop)
+	}
+}
+
+func (q *eventQueues) configFor(proxy Proxy) queueConfig {
+	if c, ok := q.overrides[proxy]; ok {
+		return c
+	}
+	return q.config
+}
+
+// push hands an event for channel ch over according to the queue policy.
+// drop, if not nil, is called for events which are never sent. It returns
+// false if the connection was shut down meanwhile.
+func (q *eventQueues) push(proxy Proxy, ch interface{}, name string, send eventSender, drop func()) bool {
+	ev := queuedEvent{send, drop}
+	q.mu.Lock()
+	config := q.configFor(proxy)
+	if config.policy == QueueBlock {
+		q.mu.Unlock()
+		if !q.deliver(proxy, name, nil, q.stop, send) {
+			ev.discard()
+			return false
+		}
+		return true
+	}
+	if config.policy == QueueDropUnsubscribed && !q.subscribed[ch] {
+		q.mu.Unlock()
+		ev.discard()
+		return true
+	}
+	size := config.size
+	if size < 1 {
+		size = 1
+	}
+	var eq *eventQueue
+	for {
+		// the pump may have drained and dropped the queue while waiting
+		var ok bool
+		if eq, ok = q.queues[ch]; !ok {
+			if q.closed {
+				q.mu.Unlock()
+				ev.discard()
+				return false
+			}
+			eq = &eventQueue{proxy: proxy, name: name, space: make(chan struct{}, 1), stop: make(chan struct{})}
+			q.queues[ch] = eq
+		}
+		if len(eq.pending) < size {
+			break
+		}
+		if config.policy == QueueDropOldest {
+			eq.pending[0].discard()
+			eq.pending = eq.pending[1:]
+			break
+		}
+		q.mu.Unlock()
+		select {
+		case <-eq.space:
+		case <-eq.stop:
+			// the proxy is gone, unless the connection shut down
+			ev.discard()
+			select {
+			case <-q.stop:
+				return false
+			default:
+				return true
+			}
+		}
+		q.mu.Lock()
+	}
+	eq.pending = append(eq.pending, ev)
+	eq.drops = config.policy == QueueDropOldest
+	if !eq.running {
+		eq.running = true
+		go q.pump(ch, eq)
+	}
+	q.mu.Unlock()
+	return true
+}
+
+// pump delivers queued events of one channel in order, so that a channel
+// nobody reads only holds up its own events.
+func (q *eventQueues) pump(ch interface{}, eq *eventQueue) {
+	for {
+		q.mu.Lock()
+		if len(eq.pending) == 0 {
+			eq.running = false
+			eq.stalled = false
+			if q.queues[ch] == eq {
+				delete(q.queues, ch)
+			}
+			q.mu.Unlock()
+			return
+		}
+		ev := eq.pending[0]
+		eq.pending = eq.pending[1:]
+		q.mu.Unlock()
+		select {
+		case eq.space <- struct{}{}:
+		default:
+		}
+		if !q.deliver(eq.proxy, eq.name, eq, eq.stop, ev.send) {
+			ev.discard()
+			return
+		}
+	}
+}
+
+// deliver runs send, reporting a stall if it takes longer than the
+// configured timeout. Queued channels report once until they drain.
+func (q *eventQueues) deliver(proxy Proxy, name string, eq *eventQueue, stop <-chan struct{}, send eventSender) bool {
+	var stall <-chan time.Time
+	q.mu.Lock()
+	drops := eq != nil && eq.drops
+	q.mu.Unlock()
+	if q.onStall != nil && q.stallTimeout > 0 && !drops {
+		timer := time.NewTimer(q.stallTimeout)
+		defer timer.Stop()
+		stall = timer.C
+	}
+	switch send(stop, stall) {
+	case sendDelivered:
+		return true
+	case sendStopped:
+		return false
+	}
+	pending, report := 1, true
+	if eq != nil {
+		q.mu.Lock()
+		pending += len(eq.pending)
+		report = !eq.stalled
+		eq.stalled = true
+		q.mu.Unlock()
+	}
+	if report {
+		q.onStall(Stall{Proxy: proxy, Channel: name, Pending: pending})
+	}
+	return send(stop, nil) == sendDelivered
+}
+
+// deliverLast runs send regardless of the queue policy and of close,
+// giving up after the stall timeout.
+func (q *eventQueues) deliverLast(send eventSender) {
+	timeout := q.stallTimeout
+	if timeout <= 0 {
+		timeout = defaultStallTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	send(nil, timer.C)
+}
+
+func (q *eventQueues) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	close(q.stop)
+	q.closed = true
+	for _, eq := range q.queues {
+		for _, e := range eq.pending {
+			e.discard()
+		}
+		eq.pending = nil
+		close(eq.stop)
+	}
+}
@@ -0,0 +1,164 @@
+package wayland
+
+import (
+	"testing"
+	"time"
+)
+
+// dispatchUntil keeps dispatching events until done is closed.
+func dispatchUntil(t *testing.T, c *Connection, done <-chan CallbackDoneEvent) {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case <-done:
+			return
+		case <-timeout:
+			t.Fatal("Timeout waiting for callback")
+		case c.Dispatch() <- true:
+		}
+	}
+}
+
+func TestQueueDropOldest(t *testing.T) {
+	display, server := newTestDisplay(t, WithQueuePolicy(QueueDropOldest, 2))
+	defer display.Connection().Close()
+	output := NewOutput(display.Connection())
+	display.Connection().register(output)
+	cb := NewCallback(display.Connection())
+	display.Connection().register(cb)
+	for i := 1; i <= 5; i++ {
+		sendEvent(t, server, output.Id(), 3, int32(i))
+	}
+	sendEvent(t, server, cb.Id(), 0, uint32(0))
+
+	dispatchUntil(t, display.Connection(), cb.DoneChan)
+
+	var factors []int32
+loop:
+	for len(factors) < 3 {
+		select {
+		case ev := <-output.ScaleChan:
+			factors = append(factors, ev.Factor)
+		case <-time.After(50 * time.Millisecond):
+			break loop
+		}
+	}
+	n := len(factors)
+	if n < 2 || factors[n-2] != 4 || factors[n-1] != 5 {
+		t.Errorf("Expected the newest events to be kept, got %v", factors)
+	}
+}
+
+func TestQueueDropUnsubscribed(t *testing.T) {
+	display, server := newTestDisplay(t, WithQueuePolicy(QueueDropUnsubscribed, 4))
+	defer display.Connection().Close()
+	output := NewOutput(display.Connection())
+	display.Connection().register(output)
+	cb := NewCallback(display.Connection())
+	display.Connection().register(cb)
+	display.Connection().Subscribe(cb.DoneChan)
+	sendEvent(t, server, output.Id(), 3, int32(2))
+	sendEvent(t, server, cb.Id(), 0, uint32(0))
+
+	dispatchUntil(t, display.Connection(), cb.DoneChan)
+
+	select {
+	case ev := <-output.ScaleChan:
+		t.Errorf("Unsubscribed event delivered: %v", ev)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestQueueBufferDoesNotBlockOtherChannels(t *testing.T) {
+	display, server := newTestDisplay(t, WithQueuePolicy(QueueBuffer, 8))
+	defer display.Connection().Close()
+	output := NewOutput(display.Connection())
+	display.Connection().register(output)
+	cb := NewCallback(display.Connection())
+	display.Connection().register(cb)
+	sendEvent(t, server, output.Id(), 3, int32(1))
+	sendEvent(t, server, output.Id(), 3, int32(2))
+	sendEvent(t, server, cb.Id(), 0, uint32(0))
+
+	dispatchUntil(t, display.Connection(), cb.DoneChan)
+
+	for i := int32(1); i <= 2; i++ {
+		if ev := <-output.ScaleChan; ev.Factor != i {
+			t.Errorf("Expected factor %d, got %d", i, ev.Factor)
+		}
+	}
+}
+
+func TestQueueStallReported(t *testing.T) {
+	stalls := make(chan Stall, 1)
+	display, server := newTestDisplay(t, WithStallHandler(10*time.Millisecond, func(s Stall) {
+		stalls <- s
+	}))
+	output := NewOutput(display.Connection())
+	display.Connection().register(output)
+	sendEvent(t, server, output.Id(), 3, int32(2))
+
+	select {
+	case s := <-stalls:
+		if s.Proxy != Proxy(output) || s.Channel != "Output.ScaleChan" {
+			t.Errorf("Unexpected stall report: %+v", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stall not reported")
+	}
+
+	closed := make(chan error)
+	go func() { closed <- display.Connection().Close() }()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked by undelivered event")
+	}
+}
+
+func TestQueueBufferKeepsOrder(t *testing.T) {
+	q := newEventQueues()
+	q.config = queueConfig{QueueBuffer, 1}
+	defer q.close()
+	ch := make(chan int)
+	const n = 2000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < n; i++ {
+			if v := <-ch; v != i {
+				t.Errorf("Event %d arrived as event %d", v, i)
+				return
+			}
+		}
+	}()
+	for i := 0; i < n; i++ {
+		if !q.push(nil, ch, "ch", chanSender(ch, i), nil) {
+			t.Fatal("Queue stopped")
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for events")
+	}
+}
+
+func TestQueueDropOldestNoStall(t *testing.T) {
+	stalls := make(chan Stall, 1)
+	display, server := newTestDisplay(t, WithStallHandler(10*time.Millisecond, func(s Stall) {
+		stalls <- s
+	}))
+	defer display.Connection().Close()
+	output := NewOutput(display.Connection())
+	display.Connection().register(output)
+	display.Connection().SetQueuePolicy(output, QueueDropOldest, 4)
+	sendEvent(t, server, output.Id(), 3, int32(2))
+	// nobody reads DeleteIdChan of the display either
+	sendEvent(t, server, 1, 1, uint32(output.Id()))
+	select {
+	case s := <-stalls:
+		t.Errorf("Stall reported for dropping queue: %+v", s)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,102 @@
+package wayland
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+const (
+	// maxMessageSize is the largest message libwayland sends or accepts.
+	maxMessageSize = 4096
+	// ringSize must be a power of two.
+	ringSize = 4 * maxMessageSize
+)
+
+// messageReader splits the byte stream of a connection into messages. It
+// reads as much as available with each recvmsg and keeps incomplete
+// messages for the next read.
+type messageReader struct {
+	conn    *net.UnixConn
+	ring    [ringSize]byte
+	head    uint // read position
+	tail    uint // write position
+	control []byte
+	fds     *fdQueue
+}
+
+func newMessageReader(conn *net.UnixConn, fds *fdQueue) *messageReader {
+	return &messageReader{conn: conn, control: newControlBuffer(), fds: fds}
+}
+
+func (r *messageReader) buffered() uint {
+	return r.tail - r.head
+}
+
+// peek copies buffered bytes starting at the read position into dst.
+func (r *messageReader) peek(dst []byte) {
+	start := r.head & (ringSize - 1)
+	n := copy(dst, r.ring[start:])
+	copy(dst[n:], r.ring[:])
+}
+
+// fill reads once from the connection into the free part of the ring.
+func (r *messageReader) fill() error {
+	start := r.tail & (ringSize - 1)
+	free := ringSize - r.buffered()
+	if start+free > ringSize {
+		free = ringSize - start
+	}
+	n, oobn, flags, _, err := r.conn.ReadMsgUnix(r.ring[start:start+free], r.control)
+	if ferr := r.fds.receive(r.control[:oobn], flags); ferr != nil {
+		return ferr
+	}
+	r.tail += uint(n)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return io.EOF
+	}
+	return nil
+}
+
+// Next returns the next complete message, reading from the connection as
+// long as necessary.
+func (r *messageReader) Next() (*Message, error) {
+	var header [8]byte
+	for {
+		if r.buffered() >= 8 {
+			r.peek(header[:])
+			size := uint(binary.LittleEndian.Uint16(header[6:8]))
+			if size < 8 || size&0x3 != 0 || size > maxMessageSize {
+				return nil, corrupted("invalid message size %d", size)
+			}
+			if r.buffered() >= size {
+				return r.take(header, size), nil
+			}
+		}
+		if err := r.fill(); err != nil {
+			if err == io.EOF && r.buffered() > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+}
+
+// take removes a complete message of the given size from the ring.
+func (r *messageReader) take(header [8]byte, size uint) *Message {
+	body := make([]byte, size)
+	r.peek(body)
+	r.head += size
+	msg := &Message{
+		Id:     ProxyId(binary.LittleEndian.Uint32(header[0:4])),
+		Opcode: uint32(binary.LittleEndian.Uint16(header[4:6])),
+		size:   uint32(size),
+		data:   bytes.NewBuffer(body[8:]),
+		fds:    r.fds,
+	}
+	return msg
+}
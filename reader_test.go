@@ -0,0 +1,98 @@
+package wayland
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// encodeMessages returns the wire format of n messages with growing bodies.
+func encodeMessages(t *testing.T, n int) ([]byte, []*Message) {
+	var wire bytes.Buffer
+	var msgs []*Message
+	for i := 0; i < n; i++ {
+		msg := newEvent(ProxyId(i+1), uint32(i%7), "message", uint32(i))
+		for j := 0; j < i%50; j++ {
+			msg.Write(int32(j))
+		}
+		msg.marshal(&wire)
+		msgs = append(msgs, msg)
+	}
+	return wire.Bytes(), msgs
+}
+
+func checkMessages(t *testing.T, r *messageReader, msgs []*Message) {
+	for i, want := range msgs {
+		got, err := r.Next()
+		if err != nil {
+			t.Fatalf("Message %d: %s", i, err)
+		}
+		if got.Id != want.Id || got.Opcode != want.Opcode || !bytes.Equal(got.data.Bytes(), want.data.Bytes()) {
+			t.Fatalf("Message %d: expected %d/%d %x, got %d/%d %x", i,
+				want.Id, want.Opcode, want.data.Bytes(), got.Id, got.Opcode, got.data.Bytes())
+		}
+	}
+}
+
+func TestReaderSplitMessages(t *testing.T) {
+	client, server := socketPair(t)
+	defer client.Close()
+	wire, msgs := encodeMessages(t, 500)
+	go func() {
+		defer server.Close()
+		for i := range wire {
+			if _, err := server.Write(wire[i : i+1]); err != nil {
+				return
+			}
+		}
+	}()
+	r := newMessageReader(client, &fdQueue{})
+	checkMessages(t, r, msgs)
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+}
+
+func TestReaderMultipleMessagesPerRead(t *testing.T) {
+	client, server := socketPair(t)
+	defer client.Close()
+	wire, msgs := encodeMessages(t, 100)
+	go func() {
+		defer server.Close()
+		server.Write(wire)
+	}()
+	checkMessages(t, newMessageReader(client, &fdQueue{}), msgs)
+}
+
+func TestReaderMaximumMessageSize(t *testing.T) {
+	client, server := socketPair(t)
+	defer client.Close()
+	defer server.Close()
+	msg := newEvent(1, 0)
+	for msg.data.Len() < maxMessageSize-8 {
+		msg.Write(uint32(msg.data.Len()))
+	}
+	go SendWaylandMessage(server, msg)
+	checkMessages(t, newMessageReader(client, &fdQueue{}), []*Message{msg})
+
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[0:], 1)
+	binary.LittleEndian.PutUint32(header[4:], (maxMessageSize+4)<<16)
+	server.Write(header[:])
+	if _, err := newMessageReader(client, &fdQueue{}).Next(); !errors.Is(err, ErrConnectionCorrupted) {
+		t.Errorf("Oversized message accepted: %v", err)
+	}
+}
+
+func TestReaderTruncatedStream(t *testing.T) {
+	client, server := socketPair(t)
+	defer client.Close()
+	wire, _ := encodeMessages(t, 1)
+	server.Write(wire[:len(wire)-1])
+	server.Close()
+	if _, err := newMessageReader(client, &fdQueue{}).Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected unexpected EOF, got %v", err)
+	}
+}
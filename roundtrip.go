@@ -0,0 +1,51 @@
+package wayland
+
+import (
+	"context"
+)
+
+// Wait blocks until the callback fires and returns its data. In manual
+// dispatch mode it keeps requesting events meanwhile.
+func (p *Callback) Wait(ctx context.Context) (uint32, error) {
+	c := p.Connection()
+	if err := c.Flush(); err != nil {
+		return 0, err
+	}
+	for {
+		select {
+		case ev := <-p.DoneChan:
+			return ev.CallbackData, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-c.Done():
+			return 0, c.Err()
+		case c.Dispatch() <- true:
+		}
+	}
+}
+
+// Roundtrip blocks until the server processed all requests sent so far.
+// It fails with a *ProtocolError if the server reports an error meanwhile.
+func (p *Display) Roundtrip(ctx context.Context) error {
+	callback, err := p.Sync()
+	if err != nil {
+		return err
+	}
+	c := p.Connection()
+	if err = c.Flush(); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-callback.DoneChan:
+			return nil
+		case <-ctx.Done():
+			// nobody is going to read the late done event
+			c.SetQueuePolicy(callback, QueueDropUnsubscribed, 0)
+			return ctx.Err()
+		case <-c.Done():
+			return c.Err()
+		case c.Dispatch() <- true:
+		}
+	}
+}
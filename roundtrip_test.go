@@ -0,0 +1,64 @@
+package wayland
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRoundtrip(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithManualDispatch()}} {
+		display, server := newTestDisplay(t, opts...)
+		go serveSync(server)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := display.Roundtrip(ctx); err != nil {
+			t.Errorf("Roundtrip failed: %s", err)
+		}
+		cancel()
+		display.Connection().Close()
+	}
+}
+
+func TestRoundtripProtocolError(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	go func() {
+		if _, err := ReadWaylandMessage(server); err == nil {
+			SendWaylandMessage(server, newEvent(1, 0, Proxy(display), uint32(DisplayErrorInvalidMethod), "bad request"))
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := display.Roundtrip(ctx)
+	var perr *ProtocolError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected a protocol error, got %v", err)
+	}
+	if perr.ObjectId != 1 || perr.Code != uint32(DisplayErrorInvalidMethod) || perr.Message != "bad request" ||
+		perr.Interface != "wl_display" || perr.CodeName != "DisplayErrorInvalidMethod" {
+		t.Errorf("Unexpected protocol error: %+v", perr)
+	}
+}
+
+func TestRoundtripCancelled(t *testing.T) {
+	display, _ := newTestDisplay(t)
+	defer display.Connection().Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := display.Roundtrip(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+}
+
+func TestCallbackWaitConnectionClosed(t *testing.T) {
+	display, _ := newTestDisplay(t)
+	cb, err := display.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	display.Connection().Close()
+	if _, err := cb.Wait(context.Background()); err != ErrConnectionClosed {
+		t.Errorf("Expected ErrConnectionClosed, got %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package wayland
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+var ErrBufferPoolClosed = errors.New("Buffer pool closed.")
+
+// ShmBuffer is a wl_buffer in the memory of a BufferPool.
+type ShmBuffer struct {
+	*Buffer
+	Width  int32
+	Height int32
+	Stride int32
+	Format ShmFormat
+	// Data are the pixels of the buffer. They stay valid until the buffer
+	// is destroyed, by Close or by Get replacing a free buffer of another
+	// size.
+	Data    []byte
+	offset  int
+	mapping *mapping
+	busy    bool
+	stop    chan struct{}
+}
+
+type region struct {
+	offset, size int
+}
+
+// mapping is a memory mapping of the pool file. Growing the pool maps the
+// file again, older mappings stay until no buffer uses them.
+type mapping struct {
+	mem  []byte
+	refs int
+}
+
+// BufferPool hands out wl_shm buffers which the compositor does not use,
+// for double or triple buffering. A buffer returned by Get is busy until
+// the compositor sends wl_buffer.release after it was attached and
+// committed. The pool memory grows as needed.
+type BufferPool struct {
+	mu       sync.Mutex
+	shm      *Shm
+	max      int
+	file     *os.File
+	pool     *ShmPool
+	size     int
+	mem      *mapping
+	free     []region
+	buffers  []*ShmBuffer
+	released chan *ShmBuffer
+	freed    chan struct{}
+	done     chan struct{}
+}
+
+// NewBufferPool returns a pool keeping at most max buffers, usually 2 or 3.
+func NewBufferPool(shm *Shm, max int) *BufferPool {
+	return &BufferPool{
+		shm:      shm,
+		max:      max,
+		released: make(chan *ShmBuffer, max),
+		freed:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Get returns a free buffer of the given size and format, creating one if
+// necessary. If all buffers are busy, it waits until the compositor
+// releases one. The release may be queued behind other events, so Get must
+// not be called from a goroutine which has to drain channels with the
+// QueueBlock or QueueBuffer policy, or the connection deadlocks. Such
+// goroutines use TryGet instead.
+func (p *BufferPool) Get(ctx context.Context, width, height, stride int32, format ShmFormat) (*ShmBuffer, error) {
+	c := p.shm.Connection()
+	for {
+		p.mu.Lock()
+		b, err := p.take(width, height, stride, format)
+		p.mu.Unlock()
+		if b != nil || err != nil {
+			return b, err
+		}
+		if err := c.Flush(); err != nil {
+			return nil, err
+		}
+		select {
+		case b := <-p.released:
+			p.mu.Lock()
+			b.busy = false
+			p.mu.Unlock()
+		case <-p.done:
+			return nil, ErrBufferPoolClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.Done():
+			return nil, c.Err()
+		case c.Dispatch() <- true:
+		}
+	}
+}
+
+// TryGet is Get without waiting. It returns nil if all buffers are busy,
+// try again after receiving from Released.
+func (p *BufferPool) TryGet(width, height, stride int32, format ShmFormat) (*ShmBuffer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.take(width, height, stride, format)
+}
+
+// Released returns a channel which receives a value when the compositor
+// released a buffer, for event loops calling TryGet.
+func (p *BufferPool) Released() <-chan struct{} {
+	return p.freed
+}
+
+// take returns a free matching buffer, replacing free buffers of another
+// size, or nil if all buffers are busy. Must be called with mu held.
+func (p *BufferPool) take(width, height, stride int32, format ShmFormat) (*ShmBuffer, error) {
+	select {
+	case <-p.done:
+		return nil, ErrBufferPoolClosed
+	default:
+	}
+drain:
+	for {
+		select {
+		case b := <-p.released:
+			b.busy = false
+		default:
+			break drain
+		}
+	}
+	var stale *ShmBuffer
+	for _, b := range p.buffers {
+		if b.busy {
+			continue
+		}
+		if b.Width == width && b.Height == height && b.Stride == stride && b.Format == format {
+			b.busy = true
+			return b, nil
+		}
+		stale = b
+	}
+	if stale != nil {
+		if err := p.destroy(stale); err != nil {
+			return nil, err
+		}
+	} else if len(p.buffers) >= p.max {
+		return nil, nil
+	}
+	b := &ShmBuffer{Width: width, Height: height, Stride: stride, Format: format, busy: true, stop: make(chan struct{})}
+	if err := p.create(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// create allocates the memory of the buffer and the wl_buffer.
+func (p *BufferPool) create(b *ShmBuffer) error {
+	size := int(b.Stride) * int(b.Height)
+	if size <= 0 || b.Width <= 0 {
+		return fmt.Errorf("Invalid buffer size %dx%d with stride %d.", b.Width, b.Height, b.Stride)
+	}
+	offset, err := p.alloc(size)
+	if err != nil {
+		return err
+	}
+	b.offset = offset
+	b.Buffer, err = p.pool.CreateBuffer(int32(offset), b.Width, b.Height, b.Stride, b.Format)
+	if err != nil {
+		p.release(region{offset, size})
+		return err
+	}
+	b.mapping = p.mem
+	b.mapping.refs++
+	b.Data = p.mem.mem[offset : offset+size]
+	// releases are forwarded, so the dispatcher never waits for the pool
+	go func(released <-chan BufferReleaseEvent) {
+		for {
+			select {
+			case <-released:
+			case <-b.stop:
+				return
+			case <-p.done:
+				return
+			}
+			select {
+			case p.released <- b:
+			case <-b.stop:
+				return
+			case <-p.done:
+				return
+			}
+			select {
+			case p.freed <- struct{}{}:
+			default:
+			}
+		}
+	}(b.ReleaseChan)
+	p.buffers = append(p.buffers, b)
+	return nil
+}
+
+// destroy removes a buffer from the pool and frees its memory.
+func (p *BufferPool) destroy(b *ShmBuffer) error {
+	for i, other := range p.buffers {
+		if other == b {
+			p.buffers = append(p.buffers[:i], p.buffers[i+1:]...)
+			break
+		}
+	}
+	close(b.stop)
+	p.release(region{b.offset, len(b.Data)})
+	p.unref(b.mapping)
+	b.Data = nil
+	return b.Destroy()
+}
+
+// unref unmaps an old mapping once no buffer uses it anymore.
+func (p *BufferPool) unref(m *mapping) {
+	m.refs--
+	if m.refs == 0 && m != p.mem {
+		syscall.Munmap(m.mem)
+	}
+}
+
+// alloc returns the offset of size free bytes of the pool, growing it if
+// necessary.
+func (p *BufferPool) alloc(size int) (int, error) {
+	for i, r := range p.free {
+		if r.size >= size {
+			p.free[i] = region{r.offset + size, r.size - size}
+			if p.free[i].size == 0 {
+				p.free = append(p.free[:i], p.free[i+1:]...)
+			}
+			return r.offset, nil
+		}
+	}
+	// grow, reusing free space at the end of the pool
+	offset := p.size
+	if n := len(p.free); n > 0 && p.free[n-1].offset+p.free[n-1].size == p.size {
+		offset = p.free[n-1].offset
+		p.free = p.free[:n-1]
+	}
+	if err := p.grow(offset + size); err != nil {
+		p.release(region{offset, p.size - offset})
+		return 0, err
+	}
+	return offset, nil
+}
+
+// release returns memory to the free list, merging adjacent regions.
+func (p *BufferPool) release(r region) {
+	if r.size <= 0 {
+		return
+	}
+	i := sort.Search(len(p.free), func(i int) bool { return p.free[i].offset > r.offset })
+	p.free = append(p.free, region{})
+	copy(p.free[i+1:], p.free[i:])
+	p.free[i] = r
+	if i+1 < len(p.free) && r.offset+r.size == p.free[i+1].offset {
+		p.free[i].size += p.free[i+1].size
+		p.free = append(p.free[:i+1], p.free[i+2:]...)
+	}
+	if i > 0 && p.free[i-1].offset+p.free[i-1].size == r.offset {
+		p.free[i-1].size += p.free[i].size
+		p.free = append(p.free[:i], p.free[i+1:]...)
+	}
+}
+
+// grow enlarges the file and the wl_shm_pool to size bytes and maps the
+// memory again. Existing buffers keep using the old mapping, which may
+// still be written by the application.
+func (p *BufferPool) grow(size int) error {
+	if p.file == nil {
+		file, err := CreateAnonymousFile(size)
+		if err != nil {
+			return err
+		}
+		pool, err := p.shm.CreatePool(file.Fd(), int32(size))
+		if err != nil {
+			file.Close()
+			return err
+		}
+		p.file, p.pool = file, pool
+	} else {
+		if err := allocate(p.file, size); err != nil {
+			return err
+		}
+		if err := p.pool.Resize(int32(size)); err != nil {
+			return err
+		}
+	}
+	mem, err := syscall.Mmap(int(p.file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	if p.mem != nil && p.mem.refs == 0 {
+		syscall.Munmap(p.mem.mem)
+	}
+	p.mem = &mapping{mem: mem}
+	p.size = size
+	return nil
+}
+
+// Close destroys all buffers and the wl_shm_pool and unmaps the memory.
+func (p *BufferPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.done:
+		return ErrBufferPoolClosed
+	default:
+	}
+	close(p.done)
+	var err error
+	for _, b := range p.buffers {
+		if derr := b.Destroy(); err == nil {
+			err = derr
+		}
+		p.unref(b.mapping)
+		b.Data = nil
+	}
+	p.buffers = nil
+	if p.pool != nil {
+		if derr := p.pool.Destroy(); err == nil {
+			err = derr
+		}
+		syscall.Munmap(p.mem.mem)
+		p.mem = nil
+		p.file.Close()
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+package wayland
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// readRequests hands the requests received by the fake server to a channel.
+func readRequests(server *net.UnixConn) <-chan *Message {
+	ch := make(chan *Message, 16)
+	go func() {
+		defer close(ch)
+		for {
+			msg, err := ReadWaylandMessage(server)
+			if err != nil {
+				return
+			}
+			ch <- msg
+		}
+	}()
+	return ch
+}
+
+func expectRequest(t *testing.T, ch <-chan *Message, id ProxyId, opcode uint32) *Message {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		if msg.Id != id || msg.Opcode != opcode {
+			t.Fatalf("Received request %d on object %d, expected %d on %d", msg.Opcode, msg.Id, opcode, id)
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("Timeout waiting for request %d on object %d", opcode, id)
+	}
+	return nil
+}
+
+func TestBufferPool(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	ctx := display.Connection()
+	requests := readRequests(server)
+	shm := NewShm(ctx)
+	ctx.register(shm)
+	pool := NewBufferPool(shm, 2)
+
+	b1, err := pool.Get(context.Background(), 10, 10, 40, ShmFormatArgb8888)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := expectRequest(t, requests, shm.Id(), 0)
+	msg.GetUint32()
+	fd, err := msg.GetFD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(int(fd))
+	if size, _ := msg.GetInt32(); size != 400 {
+		t.Errorf("Pool created with %d bytes", size)
+	}
+	expectRequest(t, requests, pool.pool.Id(), 0)
+	b1.Data[399] = 7
+	data := b1.Data
+
+	// the second buffer grows the pool, the first one keeps its memory
+	b2, err := pool.Get(context.Background(), 10, 10, 40, ShmFormatArgb8888)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := expectRequest(t, requests, pool.pool.Id(), 2).GetInt32(); size != 800 {
+		t.Errorf("Pool resized to %d bytes", size)
+	}
+	msg = expectRequest(t, requests, pool.pool.Id(), 0)
+	msg.GetUint32()
+	if offset, _ := msg.GetInt32(); offset != 400 {
+		t.Errorf("Second buffer at offset %d", offset)
+	}
+	mem, err := syscall.Mmap(int(fd), 0, 800, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Munmap(mem)
+	b2.Data[0] = 8
+	data[398] = 9
+	if &data[0] != &b1.Data[0] {
+		t.Error("Memory of a busy buffer moved when the pool grew")
+	}
+	if b1.Data[399] != 7 || mem[398] != 9 || mem[399] != 7 || mem[400] != 8 {
+		t.Error("Buffer contents not shared with the compositor")
+	}
+
+	// both buffers are busy
+	timeout, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Get(timeout, 10, 10, 40, ShmFormatArgb8888); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Unexpected result without free buffers: %v", err)
+	}
+	sendEvent(t, server, b1.Id(), 0)
+	if b, err := pool.Get(context.Background(), 10, 10, 40, ShmFormatArgb8888); b != b1 || err != nil {
+		t.Errorf("Released buffer not reused: %v", err)
+	}
+
+	// a new size replaces a released buffer
+	sendEvent(t, server, b2.Id(), 0)
+	b3, err := pool.Get(context.Background(), 20, 10, 80, ShmFormatXrgb8888)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectRequest(t, requests, b2.Id(), 0)
+	if size, _ := expectRequest(t, requests, pool.pool.Id(), 2).GetInt32(); size != 1200 {
+		t.Errorf("Pool resized to %d bytes", size)
+	}
+	msg = expectRequest(t, requests, pool.pool.Id(), 0)
+	msg.GetUint32()
+	if offset, _ := msg.GetInt32(); offset != 400 || len(b3.Data) != 800 {
+		t.Errorf("Buffer of %d bytes at offset %d", len(b3.Data), offset)
+	}
+
+	if len(b1.mapping.mem) != 400 || b2.Data != nil || len(b3.mapping.mem) != 1200 {
+		t.Error("Unexpected mappings of the buffers")
+	}
+
+	poolId := pool.pool.Id()
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expectRequest(t, requests, b1.Id(), 0)
+	expectRequest(t, requests, b3.Id(), 0)
+	expectRequest(t, requests, poolId, 1)
+	if _, err := pool.Get(context.Background(), 10, 10, 40, ShmFormatArgb8888); err != ErrBufferPoolClosed {
+		t.Errorf("Unexpected result of Get after Close: %v", err)
+	}
+}
+
+func TestBufferPoolTryGet(t *testing.T) {
+	display, server := newTestDisplay(t)
+	defer display.Connection().Close()
+	ctx := display.Connection()
+	readRequests(server)
+	shm := NewShm(ctx)
+	ctx.register(shm)
+	pool := NewBufferPool(shm, 1)
+	defer pool.Close()
+
+	b1, err := pool.TryGet(10, 10, 40, ShmFormatArgb8888)
+	if b1 == nil || err != nil {
+		t.Fatalf("No buffer from an empty pool: %v", err)
+	}
+	if b, err := pool.TryGet(10, 10, 40, ShmFormatArgb8888); b != nil || err != nil {
+		t.Fatalf("Unexpected result without free buffers: %v, %v", b, err)
+	}
+	sendEvent(t, server, b1.Id(), 0)
+	select {
+	case <-pool.Released():
+	case <-time.After(time.Second):
+		t.Fatal("Release not signalled")
+	}
+	if b, err := pool.TryGet(10, 10, 40, ShmFormatArgb8888); b != b1 || err != nil {
+		t.Errorf("Released buffer not reused: %v", err)
+	}
+}
+
+func TestBufferPoolRegions(t *testing.T) {
+	p := &BufferPool{size: 100}
+	for _, r := range []region{{60, 10}, {0, 10}, {10, 20}, {70, 30}} {
+		p.release(r)
+	}
+	if len(p.free) != 2 || p.free[0] != (region{0, 30}) || p.free[1] != (region{60, 40}) {
+		t.Fatalf("Unexpected free regions %v", p.free)
+	}
+	if offset, err := p.alloc(35); offset != 60 || err != nil {
+		t.Errorf("35 bytes allocated at %d: %v", offset, err)
+	}
+	if offset, err := p.alloc(30); offset != 0 || err != nil {
+		t.Errorf("30 bytes allocated at %d: %v", offset, err)
+	}
+	if len(p.free) != 1 || p.free[0] != (region{95, 5}) {
+		t.Errorf("Unexpected free regions %v", p.free)
+	}
+}
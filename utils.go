@@ -1,29 +1,89 @@
 package wayland
 
 import (
-	"io/ioutil"
+	"errors"
+	"fmt"
 	"math"
 	"os"
-	"syscall"
+	"strconv"
 )
 
+// CreateAnonymousFile returns a file of the given size to share memory
+// with the compositor, e.g. for a wl_shm pool. It is a sealed memfd which
+// can grow but not shrink, or an unlinked file in XDG_RUNTIME_DIR on
+// kernels without memfd. The space is allocated right away, so a full
+// tmpfs is reported here and not by SIGBUS on first access of the memory.
 func CreateAnonymousFile(size int) (*os.File, error) {
-	template := "wayland-shared"
+	ret, err := createMemfd("wayland-shared")
+	if err != nil {
+		ret, err = createTmpfile("wayland-shared")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = allocate(ret, size); err != nil {
+		ret.Close()
+		return nil, err
+	}
+	if err = seal(ret); err != nil {
+		ret.Close()
+		return nil, fmt.Errorf("Unable to seal anonymous file: %w", err)
+	}
+	return ret, nil
+}
+
+// createTmpfile creates a file in XDG_RUNTIME_DIR and unlinks it at once.
+func createTmpfile(name string) (*os.File, error) {
 	dir := os.Getenv("XDG_RUNTIME_DIR")
 	if dir == "" {
-		panic("XDG_RUNTIME_DIR not defined.")
+		return nil, errors.New("XDG_RUNTIME_DIR not set in the environment.")
 	}
-	ret, err := ioutil.TempFile(dir, template)
+	ret, err := os.CreateTemp(dir, name+"-*")
 	if err != nil {
 		return nil, err
 	}
-	err = syscall.Ftruncate(int(ret.Fd()), int64(size))
-	if err != nil {
+	if err = os.Remove(ret.Name()); err != nil {
+		ret.Close()
 		return nil, err
 	}
 	return ret, nil
 }
 
+// Fixed is the signed 24.8 fixed point number of the protocol, used for
+// coordinates. Fixed values are added and subtracted exactly with the
+// usual operators.
+type Fixed int32
+
+// FixedFromInt returns the Fixed value of i, which has to be in the range
+// of 24 bit integers.
+func FixedFromInt(i int) Fixed {
+	return Fixed(i * 256)
+}
+
+// FixedFromFloat64 returns the Fixed value nearest to v.
+func FixedFromFloat64(v float64) Fixed {
+	return Fixed(float64ToFixed(v))
+}
+
+// Float64 returns the exact value of f.
+func (f Fixed) Float64() float64 {
+	return fixedToFloat64(int32(f))
+}
+
+// Int returns the integer part of f, truncated towards zero.
+func (f Fixed) Int() int {
+	return int(f / 256)
+}
+
+// Mul returns the product of f and g, rounded to the nearest Fixed value.
+func (f Fixed) Mul(g Fixed) Fixed {
+	return Fixed((int64(f)*int64(g) + 128) >> 8)
+}
+
+func (f Fixed) String() string {
+	return strconv.FormatFloat(f.Float64(), 'f', -1, 64)
+}
+
 func fixedToFloat64(fixed int32) float64 {
 	dat := ((int64(1023 + 44)) << 52) + (1 << 51) + int64(fixed)
 	return math.Float64frombits(uint64(dat)) - float64(3<<43)
@@ -2,7 +2,9 @@ package wayland
 
 import (
 	"math"
+	"os"
 	"testing"
+	"testing/quick"
 )
 
 func TestFixedToFloat64(t *testing.T) {
@@ -33,3 +35,76 @@ func TestReverse(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestFixedRoundTrip(t *testing.T) {
+	exact := func(f int32) bool {
+		return FixedFromFloat64(Fixed(f).Float64()) == Fixed(f)
+	}
+	if err := quick.Check(exact, nil); err != nil {
+		t.Error(err)
+	}
+	nearest := func(f int32) bool {
+		// values between two Fixed steps round to the nearest one
+		v := Fixed(f).Float64() + 1.0/1024
+		return FixedFromFloat64(v) == Fixed(f)
+	}
+	if err := quick.Check(nearest, nil); err != nil {
+		t.Error(err)
+	}
+	ints := func(i int32) bool {
+		i >>= 8 // 24 bit
+		return FixedFromInt(int(i)).Int() == int(i) && FixedFromInt(int(i)).Float64() == float64(i)
+	}
+	if err := quick.Check(ints, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFixedArithmetic(t *testing.T) {
+	add := func(a, b int16) bool {
+		sum := FixedFromInt(int(a)) + FixedFromFloat64(float64(b)/256)
+		return sum.Float64() == float64(a)+float64(b)/256
+	}
+	if err := quick.Check(add, nil); err != nil {
+		t.Error(err)
+	}
+	mul := func(a, b int8) bool {
+		return FixedFromFloat64(float64(a)/2).Mul(FixedFromInt(int(b))).Float64() == float64(a)/2*float64(b)
+	}
+	if err := quick.Check(mul, nil); err != nil {
+		t.Error(err)
+	}
+	for f, i := range map[Fixed]int{-1: 0, -256: -1, -257: -1, 255: 0, 256: 1} {
+		if f.Int() != i {
+			t.Errorf("Fixed %s truncated to %d, expected %d", f, f.Int(), i)
+		}
+	}
+	// float32 loses the fraction at large coordinates
+	if f := Fixed(0x7fffff01); f.Float64() != 8388607+1.0/256 || f.String() != "8388607.00390625" {
+		t.Errorf("Large value %s not exact", f)
+	}
+}
+
+func TestCreateTmpfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	f, err := createTmpfile("wayland-shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := allocate(f, 100); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := f.Stat(); fi.Size() != 100 {
+		t.Errorf("Unexpected size %d", fi.Size())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("File left in XDG_RUNTIME_DIR: %s", entries[0].Name())
+	}
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	if _, err := createTmpfile("wayland-shared"); err == nil {
+		t.Error("Missing XDG_RUNTIME_DIR not reported")
+	}
+}
@@ -1,179 +1,1289 @@
-// Warning. This file was generated by wayland-generator
-// and should not be changed manually.
+// Code generated by wayland-go-scanner from wayland.xml. DO NOT EDIT.
+
 package wayland
 
+import "strconv"
+
+type DisplayError uint32
+
 const (
-	DisplayErrorInvalidObject = 0
-	DisplayErrorInvalidMethod = 1
-	DisplayErrorNoMemory      = 2
+	DisplayErrorInvalidObject  DisplayError = 0
+	DisplayErrorInvalidMethod  DisplayError = 1
+	DisplayErrorNoMemory       DisplayError = 2
+	DisplayErrorImplementation DisplayError = 3
 )
 
+func (e DisplayError) String() string {
+	switch e {
+	case DisplayErrorInvalidObject:
+		return "invalid_object"
+	case DisplayErrorInvalidMethod:
+		return "invalid_method"
+	case DisplayErrorNoMemory:
+		return "no_memory"
+	case DisplayErrorImplementation:
+		return "implementation"
+	}
+	return "DisplayError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e DisplayError) IsValid() bool {
+	switch e {
+	case DisplayErrorInvalidObject,
+		DisplayErrorInvalidMethod,
+		DisplayErrorNoMemory,
+		DisplayErrorImplementation:
+		return true
+	}
+	return false
+}
+
+type ShmError uint32
+
 const (
-	ShmErrorInvalidFormat = 0
-	ShmErrorInvalidStride = 1
-	ShmErrorInvalidFd     = 2
+	ShmErrorInvalidFormat ShmError = 0
+	ShmErrorInvalidStride ShmError = 1
+	ShmErrorInvalidFd     ShmError = 2
 )
 
+func (e ShmError) String() string {
+	switch e {
+	case ShmErrorInvalidFormat:
+		return "invalid_format"
+	case ShmErrorInvalidStride:
+		return "invalid_stride"
+	case ShmErrorInvalidFd:
+		return "invalid_fd"
+	}
+	return "ShmError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e ShmError) IsValid() bool {
+	switch e {
+	case ShmErrorInvalidFormat,
+		ShmErrorInvalidStride,
+		ShmErrorInvalidFd:
+		return true
+	}
+	return false
+}
+
+type ShmFormat uint32
+
+const (
+	ShmFormatArgb8888             ShmFormat = 0
+	ShmFormatXrgb8888             ShmFormat = 1
+	ShmFormatC8                   ShmFormat = 0x20203843
+	ShmFormatRgb332               ShmFormat = 0x38424752
+	ShmFormatBgr233               ShmFormat = 0x38524742
+	ShmFormatXrgb4444             ShmFormat = 0x32315258
+	ShmFormatXbgr4444             ShmFormat = 0x32314258
+	ShmFormatRgbx4444             ShmFormat = 0x32315852
+	ShmFormatBgrx4444             ShmFormat = 0x32315842
+	ShmFormatArgb4444             ShmFormat = 0x32315241
+	ShmFormatAbgr4444             ShmFormat = 0x32314241
+	ShmFormatRgba4444             ShmFormat = 0x32314152
+	ShmFormatBgra4444             ShmFormat = 0x32314142
+	ShmFormatXrgb1555             ShmFormat = 0x35315258
+	ShmFormatXbgr1555             ShmFormat = 0x35314258
+	ShmFormatRgbx5551             ShmFormat = 0x35315852
+	ShmFormatBgrx5551             ShmFormat = 0x35315842
+	ShmFormatArgb1555             ShmFormat = 0x35315241
+	ShmFormatAbgr1555             ShmFormat = 0x35314241
+	ShmFormatRgba5551             ShmFormat = 0x35314152
+	ShmFormatBgra5551             ShmFormat = 0x35314142
+	ShmFormatRgb565               ShmFormat = 0x36314752
+	ShmFormatBgr565               ShmFormat = 0x36314742
+	ShmFormatRgb888               ShmFormat = 0x34324752
+	ShmFormatBgr888               ShmFormat = 0x34324742
+	ShmFormatXbgr8888             ShmFormat = 0x34324258
+	ShmFormatRgbx8888             ShmFormat = 0x34325852
+	ShmFormatBgrx8888             ShmFormat = 0x34325842
+	ShmFormatAbgr8888             ShmFormat = 0x34324241
+	ShmFormatRgba8888             ShmFormat = 0x34324152
+	ShmFormatBgra8888             ShmFormat = 0x34324142
+	ShmFormatXrgb2101010          ShmFormat = 0x30335258
+	ShmFormatXbgr2101010          ShmFormat = 0x30334258
+	ShmFormatRgbx1010102          ShmFormat = 0x30335852
+	ShmFormatBgrx1010102          ShmFormat = 0x30335842
+	ShmFormatArgb2101010          ShmFormat = 0x30335241
+	ShmFormatAbgr2101010          ShmFormat = 0x30334241
+	ShmFormatRgba1010102          ShmFormat = 0x30334152
+	ShmFormatBgra1010102          ShmFormat = 0x30334142
+	ShmFormatYuyv                 ShmFormat = 0x56595559
+	ShmFormatYvyu                 ShmFormat = 0x55595659
+	ShmFormatUyvy                 ShmFormat = 0x59565955
+	ShmFormatVyuy                 ShmFormat = 0x59555956
+	ShmFormatAyuv                 ShmFormat = 0x56555941
+	ShmFormatNv12                 ShmFormat = 0x3231564e
+	ShmFormatNv21                 ShmFormat = 0x3132564e
+	ShmFormatNv16                 ShmFormat = 0x3631564e
+	ShmFormatNv61                 ShmFormat = 0x3136564e
+	ShmFormatYuv410               ShmFormat = 0x39565559
+	ShmFormatYvu410               ShmFormat = 0x39555659
+	ShmFormatYuv411               ShmFormat = 0x31315559
+	ShmFormatYvu411               ShmFormat = 0x31315659
+	ShmFormatYuv420               ShmFormat = 0x32315559
+	ShmFormatYvu420               ShmFormat = 0x32315659
+	ShmFormatYuv422               ShmFormat = 0x36315559
+	ShmFormatYvu422               ShmFormat = 0x36315659
+	ShmFormatYuv444               ShmFormat = 0x34325559
+	ShmFormatYvu444               ShmFormat = 0x34325659
+	ShmFormatR8                   ShmFormat = 0x20203852
+	ShmFormatR16                  ShmFormat = 0x20363152
+	ShmFormatRg88                 ShmFormat = 0x38384752
+	ShmFormatGr88                 ShmFormat = 0x38385247
+	ShmFormatRg1616               ShmFormat = 0x32334752
+	ShmFormatGr1616               ShmFormat = 0x32335247
+	ShmFormatXrgb16161616f        ShmFormat = 0x48345258
+	ShmFormatXbgr16161616f        ShmFormat = 0x48344258
+	ShmFormatArgb16161616f        ShmFormat = 0x48345241
+	ShmFormatAbgr16161616f        ShmFormat = 0x48344241
+	ShmFormatXyuv8888             ShmFormat = 0x56555958
+	ShmFormatVuy888               ShmFormat = 0x34325556
+	ShmFormatVuy101010            ShmFormat = 0x30335556
+	ShmFormatY210                 ShmFormat = 0x30313259
+	ShmFormatY212                 ShmFormat = 0x32313259
+	ShmFormatY216                 ShmFormat = 0x36313259
+	ShmFormatY410                 ShmFormat = 0x30313459
+	ShmFormatY412                 ShmFormat = 0x32313459
+	ShmFormatY416                 ShmFormat = 0x36313459
+	ShmFormatXvyu2101010          ShmFormat = 0x30335658
+	ShmFormatXvyu1216161616       ShmFormat = 0x36335658
+	ShmFormatXvyu16161616         ShmFormat = 0x38345658
+	ShmFormatY0l0                 ShmFormat = 0x304c3059
+	ShmFormatX0l0                 ShmFormat = 0x304c3058
+	ShmFormatY0l2                 ShmFormat = 0x324c3059
+	ShmFormatX0l2                 ShmFormat = 0x324c3058
+	ShmFormatYuv4208bit           ShmFormat = 0x38305559
+	ShmFormatYuv42010bit          ShmFormat = 0x30315559
+	ShmFormatXrgb8888A8           ShmFormat = 0x38415258
+	ShmFormatXbgr8888A8           ShmFormat = 0x38414258
+	ShmFormatRgbx8888A8           ShmFormat = 0x38415852
+	ShmFormatBgrx8888A8           ShmFormat = 0x38415842
+	ShmFormatRgb888A8             ShmFormat = 0x38413852
+	ShmFormatBgr888A8             ShmFormat = 0x38413842
+	ShmFormatRgb565A8             ShmFormat = 0x38413552
+	ShmFormatBgr565A8             ShmFormat = 0x38413542
+	ShmFormatNv24                 ShmFormat = 0x3432564e
+	ShmFormatNv42                 ShmFormat = 0x3234564e
+	ShmFormatP210                 ShmFormat = 0x30313250
+	ShmFormatP010                 ShmFormat = 0x30313050
+	ShmFormatP012                 ShmFormat = 0x32313050
+	ShmFormatP016                 ShmFormat = 0x36313050
+	ShmFormatAxbxgxrx106106106106 ShmFormat = 0x30314241
+	ShmFormatNv15                 ShmFormat = 0x3531564e
+	ShmFormatQ410                 ShmFormat = 0x30313451
+	ShmFormatQ401                 ShmFormat = 0x31303451
+	ShmFormatXrgb16161616         ShmFormat = 0x38345258
+	ShmFormatXbgr16161616         ShmFormat = 0x38344258
+	ShmFormatArgb16161616         ShmFormat = 0x38345241
+	ShmFormatAbgr16161616         ShmFormat = 0x38344241
+	ShmFormatC1                   ShmFormat = 0x20203143
+	ShmFormatC2                   ShmFormat = 0x20203243
+	ShmFormatC4                   ShmFormat = 0x20203443
+	ShmFormatD1                   ShmFormat = 0x20203144
+	ShmFormatD2                   ShmFormat = 0x20203244
+	ShmFormatD4                   ShmFormat = 0x20203444
+	ShmFormatD8                   ShmFormat = 0x20203844
+	ShmFormatR1                   ShmFormat = 0x20203152
+	ShmFormatR2                   ShmFormat = 0x20203252
+	ShmFormatR4                   ShmFormat = 0x20203452
+	ShmFormatR10                  ShmFormat = 0x20303152
+	ShmFormatR12                  ShmFormat = 0x20323152
+	ShmFormatAvuy8888             ShmFormat = 0x59555641
+	ShmFormatXvuy8888             ShmFormat = 0x59555658
+	ShmFormatP030                 ShmFormat = 0x30333050
+)
+
+func (e ShmFormat) String() string {
+	switch e {
+	case ShmFormatArgb8888:
+		return "argb8888"
+	case ShmFormatXrgb8888:
+		return "xrgb8888"
+	case ShmFormatC8:
+		return "c8"
+	case ShmFormatRgb332:
+		return "rgb332"
+	case ShmFormatBgr233:
+		return "bgr233"
+	case ShmFormatXrgb4444:
+		return "xrgb4444"
+	case ShmFormatXbgr4444:
+		return "xbgr4444"
+	case ShmFormatRgbx4444:
+		return "rgbx4444"
+	case ShmFormatBgrx4444:
+		return "bgrx4444"
+	case ShmFormatArgb4444:
+		return "argb4444"
+	case ShmFormatAbgr4444:
+		return "abgr4444"
+	case ShmFormatRgba4444:
+		return "rgba4444"
+	case ShmFormatBgra4444:
+		return "bgra4444"
+	case ShmFormatXrgb1555:
+		return "xrgb1555"
+	case ShmFormatXbgr1555:
+		return "xbgr1555"
+	case ShmFormatRgbx5551:
+		return "rgbx5551"
+	case ShmFormatBgrx5551:
+		return "bgrx5551"
+	case ShmFormatArgb1555:
+		return "argb1555"
+	case ShmFormatAbgr1555:
+		return "abgr1555"
+	case ShmFormatRgba5551:
+		return "rgba5551"
+	case ShmFormatBgra5551:
+		return "bgra5551"
+	case ShmFormatRgb565:
+		return "rgb565"
+	case ShmFormatBgr565:
+		return "bgr565"
+	case ShmFormatRgb888:
+		return "rgb888"
+	case ShmFormatBgr888:
+		return "bgr888"
+	case ShmFormatXbgr8888:
+		return "xbgr8888"
+	case ShmFormatRgbx8888:
+		return "rgbx8888"
+	case ShmFormatBgrx8888:
+		return "bgrx8888"
+	case ShmFormatAbgr8888:
+		return "abgr8888"
+	case ShmFormatRgba8888:
+		return "rgba8888"
+	case ShmFormatBgra8888:
+		return "bgra8888"
+	case ShmFormatXrgb2101010:
+		return "xrgb2101010"
+	case ShmFormatXbgr2101010:
+		return "xbgr2101010"
+	case ShmFormatRgbx1010102:
+		return "rgbx1010102"
+	case ShmFormatBgrx1010102:
+		return "bgrx1010102"
+	case ShmFormatArgb2101010:
+		return "argb2101010"
+	case ShmFormatAbgr2101010:
+		return "abgr2101010"
+	case ShmFormatRgba1010102:
+		return "rgba1010102"
+	case ShmFormatBgra1010102:
+		return "bgra1010102"
+	case ShmFormatYuyv:
+		return "yuyv"
+	case ShmFormatYvyu:
+		return "yvyu"
+	case ShmFormatUyvy:
+		return "uyvy"
+	case ShmFormatVyuy:
+		return "vyuy"
+	case ShmFormatAyuv:
+		return "ayuv"
+	case ShmFormatNv12:
+		return "nv12"
+	case ShmFormatNv21:
+		return "nv21"
+	case ShmFormatNv16:
+		return "nv16"
+	case ShmFormatNv61:
+		return "nv61"
+	case ShmFormatYuv410:
+		return "yuv410"
+	case ShmFormatYvu410:
+		return "yvu410"
+	case ShmFormatYuv411:
+		return "yuv411"
+	case ShmFormatYvu411:
+		return "yvu411"
+	case ShmFormatYuv420:
+		return "yuv420"
+	case ShmFormatYvu420:
+		return "yvu420"
+	case ShmFormatYuv422:
+		return "yuv422"
+	case ShmFormatYvu422:
+		return "yvu422"
+	case ShmFormatYuv444:
+		return "yuv444"
+	case ShmFormatYvu444:
+		return "yvu444"
+	case ShmFormatR8:
+		return "r8"
+	case ShmFormatR16:
+		return "r16"
+	case ShmFormatRg88:
+		return "rg88"
+	case ShmFormatGr88:
+		return "gr88"
+	case ShmFormatRg1616:
+		return "rg1616"
+	case ShmFormatGr1616:
+		return "gr1616"
+	case ShmFormatXrgb16161616f:
+		return "xrgb16161616f"
+	case ShmFormatXbgr16161616f:
+		return "xbgr16161616f"
+	case ShmFormatArgb16161616f:
+		return "argb16161616f"
+	case ShmFormatAbgr16161616f:
+		return "abgr16161616f"
+	case ShmFormatXyuv8888:
+		return "xyuv8888"
+	case ShmFormatVuy888:
+		return "vuy888"
+	case ShmFormatVuy101010:
+		return "vuy101010"
+	case ShmFormatY210:
+		return "y210"
+	case ShmFormatY212:
+		return "y212"
+	case ShmFormatY216:
+		return "y216"
+	case ShmFormatY410:
+		return "y410"
+	case ShmFormatY412:
+		return "y412"
+	case ShmFormatY416:
+		return "y416"
+	case ShmFormatXvyu2101010:
+		return "xvyu2101010"
+	case ShmFormatXvyu1216161616:
+		return "xvyu12_16161616"
+	case ShmFormatXvyu16161616:
+		return "xvyu16161616"
+	case ShmFormatY0l0:
+		return "y0l0"
+	case ShmFormatX0l0:
+		return "x0l0"
+	case ShmFormatY0l2:
+		return "y0l2"
+	case ShmFormatX0l2:
+		return "x0l2"
+	case ShmFormatYuv4208bit:
+		return "yuv420_8bit"
+	case ShmFormatYuv42010bit:
+		return "yuv420_10bit"
+	case ShmFormatXrgb8888A8:
+		return "xrgb8888_a8"
+	case ShmFormatXbgr8888A8:
+		return "xbgr8888_a8"
+	case ShmFormatRgbx8888A8:
+		return "rgbx8888_a8"
+	case ShmFormatBgrx8888A8:
+		return "bgrx8888_a8"
+	case ShmFormatRgb888A8:
+		return "rgb888_a8"
+	case ShmFormatBgr888A8:
+		return "bgr888_a8"
+	case ShmFormatRgb565A8:
+		return "rgb565_a8"
+	case ShmFormatBgr565A8:
+		return "bgr565_a8"
+	case ShmFormatNv24:
+		return "nv24"
+	case ShmFormatNv42:
+		return "nv42"
+	case ShmFormatP210:
+		return "p210"
+	case ShmFormatP010:
+		return "p010"
+	case ShmFormatP012:
+		return "p012"
+	case ShmFormatP016:
+		return "p016"
+	case ShmFormatAxbxgxrx106106106106:
+		return "axbxgxrx106106106106"
+	case ShmFormatNv15:
+		return "nv15"
+	case ShmFormatQ410:
+		return "q410"
+	case ShmFormatQ401:
+		return "q401"
+	case ShmFormatXrgb16161616:
+		return "xrgb16161616"
+	case ShmFormatXbgr16161616:
+		return "xbgr16161616"
+	case ShmFormatArgb16161616:
+		return "argb16161616"
+	case ShmFormatAbgr16161616:
+		return "abgr16161616"
+	case ShmFormatC1:
+		return "c1"
+	case ShmFormatC2:
+		return "c2"
+	case ShmFormatC4:
+		return "c4"
+	case ShmFormatD1:
+		return "d1"
+	case ShmFormatD2:
+		return "d2"
+	case ShmFormatD4:
+		return "d4"
+	case ShmFormatD8:
+		return "d8"
+	case ShmFormatR1:
+		return "r1"
+	case ShmFormatR2:
+		return "r2"
+	case ShmFormatR4:
+		return "r4"
+	case ShmFormatR10:
+		return "r10"
+	case ShmFormatR12:
+		return "r12"
+	case ShmFormatAvuy8888:
+		return "avuy8888"
+	case ShmFormatXvuy8888:
+		return "xvuy8888"
+	case ShmFormatP030:
+		return "p030"
+	}
+	return "ShmFormat(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e ShmFormat) IsValid() bool {
+	switch e {
+	case ShmFormatArgb8888,
+		ShmFormatXrgb8888,
+		ShmFormatC8,
+		ShmFormatRgb332,
+		ShmFormatBgr233,
+		ShmFormatXrgb4444,
+		ShmFormatXbgr4444,
+		ShmFormatRgbx4444,
+		ShmFormatBgrx4444,
+		ShmFormatArgb4444,
+		ShmFormatAbgr4444,
+		ShmFormatRgba4444,
+		ShmFormatBgra4444,
+		ShmFormatXrgb1555,
+		ShmFormatXbgr1555,
+		ShmFormatRgbx5551,
+		ShmFormatBgrx5551,
+		ShmFormatArgb1555,
+		ShmFormatAbgr1555,
+		ShmFormatRgba5551,
+		ShmFormatBgra5551,
+		ShmFormatRgb565,
+		ShmFormatBgr565,
+		ShmFormatRgb888,
+		ShmFormatBgr888,
+		ShmFormatXbgr8888,
+		ShmFormatRgbx8888,
+		ShmFormatBgrx8888,
+		ShmFormatAbgr8888,
+		ShmFormatRgba8888,
+		ShmFormatBgra8888,
+		ShmFormatXrgb2101010,
+		ShmFormatXbgr2101010,
+		ShmFormatRgbx1010102,
+		ShmFormatBgrx1010102,
+		ShmFormatArgb2101010,
+		ShmFormatAbgr2101010,
+		ShmFormatRgba1010102,
+		ShmFormatBgra1010102,
+		ShmFormatYuyv,
+		ShmFormatYvyu,
+		ShmFormatUyvy,
+		ShmFormatVyuy,
+		ShmFormatAyuv,
+		ShmFormatNv12,
+		ShmFormatNv21,
+		ShmFormatNv16,
+		ShmFormatNv61,
+		ShmFormatYuv410,
+		ShmFormatYvu410,
+		ShmFormatYuv411,
+		ShmFormatYvu411,
+		ShmFormatYuv420,
+		ShmFormatYvu420,
+		ShmFormatYuv422,
+		ShmFormatYvu422,
+		ShmFormatYuv444,
+		ShmFormatYvu444,
+		ShmFormatR8,
+		ShmFormatR16,
+		ShmFormatRg88,
+		ShmFormatGr88,
+		ShmFormatRg1616,
+		ShmFormatGr1616,
+		ShmFormatXrgb16161616f,
+		ShmFormatXbgr16161616f,
+		ShmFormatArgb16161616f,
+		ShmFormatAbgr16161616f,
+		ShmFormatXyuv8888,
+		ShmFormatVuy888,
+		ShmFormatVuy101010,
+		ShmFormatY210,
+		ShmFormatY212,
+		ShmFormatY216,
+		ShmFormatY410,
+		ShmFormatY412,
+		ShmFormatY416,
+		ShmFormatXvyu2101010,
+		ShmFormatXvyu1216161616,
+		ShmFormatXvyu16161616,
+		ShmFormatY0l0,
+		ShmFormatX0l0,
+		ShmFormatY0l2,
+		ShmFormatX0l2,
+		ShmFormatYuv4208bit,
+		ShmFormatYuv42010bit,
+		ShmFormatXrgb8888A8,
+		ShmFormatXbgr8888A8,
+		ShmFormatRgbx8888A8,
+		ShmFormatBgrx8888A8,
+		ShmFormatRgb888A8,
+		ShmFormatBgr888A8,
+		ShmFormatRgb565A8,
+		ShmFormatBgr565A8,
+		ShmFormatNv24,
+		ShmFormatNv42,
+		ShmFormatP210,
+		ShmFormatP010,
+		ShmFormatP012,
+		ShmFormatP016,
+		ShmFormatAxbxgxrx106106106106,
+		ShmFormatNv15,
+		ShmFormatQ410,
+		ShmFormatQ401,
+		ShmFormatXrgb16161616,
+		ShmFormatXbgr16161616,
+		ShmFormatArgb16161616,
+		ShmFormatAbgr16161616,
+		ShmFormatC1,
+		ShmFormatC2,
+		ShmFormatC4,
+		ShmFormatD1,
+		ShmFormatD2,
+		ShmFormatD4,
+		ShmFormatD8,
+		ShmFormatR1,
+		ShmFormatR2,
+		ShmFormatR4,
+		ShmFormatR10,
+		ShmFormatR12,
+		ShmFormatAvuy8888,
+		ShmFormatXvuy8888,
+		ShmFormatP030:
+		return true
+	}
+	return false
+}
+
+type DataOfferError uint32
+
+const (
+	DataOfferErrorInvalidFinish     DataOfferError = 0
+	DataOfferErrorInvalidActionMask DataOfferError = 1
+	DataOfferErrorInvalidAction     DataOfferError = 2
+	DataOfferErrorInvalidOffer      DataOfferError = 3
+)
+
+func (e DataOfferError) String() string {
+	switch e {
+	case DataOfferErrorInvalidFinish:
+		return "invalid_finish"
+	case DataOfferErrorInvalidActionMask:
+		return "invalid_action_mask"
+	case DataOfferErrorInvalidAction:
+		return "invalid_action"
+	case DataOfferErrorInvalidOffer:
+		return "invalid_offer"
+	}
+	return "DataOfferError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e DataOfferError) IsValid() bool {
+	switch e {
+	case DataOfferErrorInvalidFinish,
+		DataOfferErrorInvalidActionMask,
+		DataOfferErrorInvalidAction,
+		DataOfferErrorInvalidOffer:
+		return true
+	}
+	return false
+}
+
+type DataSourceError uint32
+
 const (
-	ShmFormatArgb8888    = 0
-	ShmFormatXrgb8888    = 1
-	ShmFormatC8          = 0x20203843
-	ShmFormatRgb332      = 0x38424752
-	ShmFormatBgr233      = 0x38524742
-	ShmFormatXrgb4444    = 0x32315258
-	ShmFormatXbgr4444    = 0x32314258
-	ShmFormatRgbx4444    = 0x32315852
-	ShmFormatBgrx4444    = 0x32315842
-	ShmFormatArgb4444    = 0x32315241
-	ShmFormatAbgr4444    = 0x32314241
-	ShmFormatRgba4444    = 0x32314152
-	ShmFormatBgra4444    = 0x32314142
-	ShmFormatXrgb1555    = 0x35315258
-	ShmFormatXbgr1555    = 0x35314258
-	ShmFormatRgbx5551    = 0x35315852
-	ShmFormatBgrx5551    = 0x35315842
-	ShmFormatArgb1555    = 0x35315241
-	ShmFormatAbgr1555    = 0x35314241
-	ShmFormatRgba5551    = 0x35314152
-	ShmFormatBgra5551    = 0x35314142
-	ShmFormatRgb565      = 0x36314752
-	ShmFormatBgr565      = 0x36314742
-	ShmFormatRgb888      = 0x34324752
-	ShmFormatBgr888      = 0x34324742
-	ShmFormatXbgr8888    = 0x34324258
-	ShmFormatRgbx8888    = 0x34325852
-	ShmFormatBgrx8888    = 0x34325842
-	ShmFormatAbgr8888    = 0x34324241
-	ShmFormatRgba8888    = 0x34324152
-	ShmFormatBgra8888    = 0x34324142
-	ShmFormatXrgb2101010 = 0x30335258
-	ShmFormatXbgr2101010 = 0x30334258
-	ShmFormatRgbx1010102 = 0x30335852
-	ShmFormatBgrx1010102 = 0x30335842
-	ShmFormatArgb2101010 = 0x30335241
-	ShmFormatAbgr2101010 = 0x30334241
-	ShmFormatRgba1010102 = 0x30334152
-	ShmFormatBgra1010102 = 0x30334142
-	ShmFormatYuyv        = 0x56595559
-	ShmFormatYvyu        = 0x55595659
-	ShmFormatUyvy        = 0x59565955
-	ShmFormatVyuy        = 0x59555956
-	ShmFormatAyuv        = 0x56555941
-	ShmFormatNv12        = 0x3231564e
-	ShmFormatNv21        = 0x3132564e
-	ShmFormatNv16        = 0x3631564e
-	ShmFormatNv61        = 0x3136564e
-	ShmFormatYuv410      = 0x39565559
-	ShmFormatYvu410      = 0x39555659
-	ShmFormatYuv411      = 0x31315559
-	ShmFormatYvu411      = 0x31315659
-	ShmFormatYuv420      = 0x32315559
-	ShmFormatYvu420      = 0x32315659
-	ShmFormatYuv422      = 0x36315559
-	ShmFormatYvu422      = 0x36315659
-	ShmFormatYuv444      = 0x34325559
-	ShmFormatYvu444      = 0x34325659
+	DataSourceErrorInvalidActionMask DataSourceError = 0
+	DataSourceErrorInvalidSource     DataSourceError = 1
 )
 
+func (e DataSourceError) String() string {
+	switch e {
+	case DataSourceErrorInvalidActionMask:
+		return "invalid_action_mask"
+	case DataSourceErrorInvalidSource:
+		return "invalid_source"
+	}
+	return "DataSourceError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e DataSourceError) IsValid() bool {
+	switch e {
+	case DataSourceErrorInvalidActionMask,
+		DataSourceErrorInvalidSource:
+		return true
+	}
+	return false
+}
+
+type DataDeviceError uint32
+
 const (
-	DataDeviceErrorRole = 0
+	DataDeviceErrorRole       DataDeviceError = 0
+	DataDeviceErrorUsedSource DataDeviceError = 1
 )
 
+func (e DataDeviceError) String() string {
+	switch e {
+	case DataDeviceErrorRole:
+		return "role"
+	case DataDeviceErrorUsedSource:
+		return "used_source"
+	}
+	return "DataDeviceError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e DataDeviceError) IsValid() bool {
+	switch e {
+	case DataDeviceErrorRole,
+		DataDeviceErrorUsedSource:
+		return true
+	}
+	return false
+}
+
+type DataDeviceManagerDndAction uint32
+
 const (
-	ShellErrorRole = 0
+	DataDeviceManagerDndActionNone DataDeviceManagerDndAction = 0
+	DataDeviceManagerDndActionCopy DataDeviceManagerDndAction = 1
+	DataDeviceManagerDndActionMove DataDeviceManagerDndAction = 2
+	DataDeviceManagerDndActionAsk  DataDeviceManagerDndAction = 4
 )
 
+func (e DataDeviceManagerDndAction) String() string {
+	s := ""
+	if e&DataDeviceManagerDndActionCopy != 0 {
+		s += "|copy"
+	}
+	if e&DataDeviceManagerDndActionMove != 0 {
+		s += "|move"
+	}
+	if e&DataDeviceManagerDndActionAsk != 0 {
+		s += "|ask"
+	}
+	if rest := e &^ (DataDeviceManagerDndActionCopy | DataDeviceManagerDndActionMove | DataDeviceManagerDndActionAsk); rest != 0 {
+		s += "|0x" + strconv.FormatUint(uint64(rest), 16)
+	}
+	if s == "" {
+		return "none"
+	}
+	return s[1:]
+}
+
+func (e DataDeviceManagerDndAction) Has(flags DataDeviceManagerDndAction) bool {
+	return e&flags == flags
+}
+
+func (e DataDeviceManagerDndAction) IsValid() bool {
+	return e&^(DataDeviceManagerDndActionCopy|DataDeviceManagerDndActionMove|DataDeviceManagerDndActionAsk) == 0
+}
+
+type ShellError uint32
+
 const (
-	ShellSurfaceResizeNone        = 0
-	ShellSurfaceResizeTop         = 1
-	ShellSurfaceResizeBottom      = 2
-	ShellSurfaceResizeLeft        = 4
-	ShellSurfaceResizeTopLeft     = 5
-	ShellSurfaceResizeBottomLeft  = 6
-	ShellSurfaceResizeRight       = 8
-	ShellSurfaceResizeTopRight    = 9
-	ShellSurfaceResizeBottomRight = 10
+	ShellErrorRole ShellError = 0
 )
 
+func (e ShellError) String() string {
+	switch e {
+	case ShellErrorRole:
+		return "role"
+	}
+	return "ShellError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e ShellError) IsValid() bool {
+	switch e {
+	case ShellErrorRole:
+		return true
+	}
+	return false
+}
+
+type ShellSurfaceResize uint32
+
+const (
+	ShellSurfaceResizeNone        ShellSurfaceResize = 0
+	ShellSurfaceResizeTop         ShellSurfaceResize = 1
+	ShellSurfaceResizeBottom      ShellSurfaceResize = 2
+	ShellSurfaceResizeLeft        ShellSurfaceResize = 4
+	ShellSurfaceResizeTopLeft     ShellSurfaceResize = 5
+	ShellSurfaceResizeBottomLeft  ShellSurfaceResize = 6
+	ShellSurfaceResizeRight       ShellSurfaceResize = 8
+	ShellSurfaceResizeTopRight    ShellSurfaceResize = 9
+	ShellSurfaceResizeBottomRight ShellSurfaceResize = 10
+)
+
+func (e ShellSurfaceResize) String() string {
+	s := ""
+	if e&ShellSurfaceResizeTop != 0 {
+		s += "|top"
+	}
+	if e&ShellSurfaceResizeBottom != 0 {
+		s += "|bottom"
+	}
+	if e&ShellSurfaceResizeLeft != 0 {
+		s += "|left"
+	}
+	if e&ShellSurfaceResizeRight != 0 {
+		s += "|right"
+	}
+	if rest := e &^ (ShellSurfaceResizeTop | ShellSurfaceResizeBottom | ShellSurfaceResizeLeft | ShellSurfaceResizeRight); rest != 0 {
+		s += "|0x" + strconv.FormatUint(uint64(rest), 16)
+	}
+	if s == "" {
+		return "none"
+	}
+	return s[1:]
+}
+
+func (e ShellSurfaceResize) Has(flags ShellSurfaceResize) bool {
+	return e&flags == flags
+}
+
+func (e ShellSurfaceResize) IsValid() bool {
+	return e&^(ShellSurfaceResizeTop|ShellSurfaceResizeBottom|ShellSurfaceResizeLeft|ShellSurfaceResizeRight) == 0
+}
+
+type ShellSurfaceTransient uint32
+
+const (
+	ShellSurfaceTransientInactive ShellSurfaceTransient = 0x1
+)
+
+func (e ShellSurfaceTransient) String() string {
+	s := ""
+	if e&ShellSurfaceTransientInactive != 0 {
+		s += "|inactive"
+	}
+	if rest := e &^ (ShellSurfaceTransientInactive); rest != 0 {
+		s += "|0x" + strconv.FormatUint(uint64(rest), 16)
+	}
+	if s == "" {
+		return "0"
+	}
+	return s[1:]
+}
+
+func (e ShellSurfaceTransient) Has(flags ShellSurfaceTransient) bool {
+	return e&flags == flags
+}
+
+func (e ShellSurfaceTransient) IsValid() bool {
+	return e&^(ShellSurfaceTransientInactive) == 0
+}
+
+type ShellSurfaceFullscreenMethod uint32
+
+const (
+	ShellSurfaceFullscreenMethodDefault ShellSurfaceFullscreenMethod = 0
+	ShellSurfaceFullscreenMethodScale   ShellSurfaceFullscreenMethod = 1
+	ShellSurfaceFullscreenMethodDriver  ShellSurfaceFullscreenMethod = 2
+	ShellSurfaceFullscreenMethodFill    ShellSurfaceFullscreenMethod = 3
+)
+
+func (e ShellSurfaceFullscreenMethod) String() string {
+	switch e {
+	case ShellSurfaceFullscreenMethodDefault:
+		return "default"
+	case ShellSurfaceFullscreenMethodScale:
+		return "scale"
+	case ShellSurfaceFullscreenMethodDriver:
+		return "driver"
+	case ShellSurfaceFullscreenMethodFill:
+		return "fill"
+	}
+	return "ShellSurfaceFullscreenMethod(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e ShellSurfaceFullscreenMethod) IsValid() bool {
+	switch e {
+	case ShellSurfaceFullscreenMethodDefault,
+		ShellSurfaceFullscreenMethodScale,
+		ShellSurfaceFullscreenMethodDriver,
+		ShellSurfaceFullscreenMethodFill:
+		return true
+	}
+	return false
+}
+
+type SurfaceError uint32
+
+const (
+	SurfaceErrorInvalidScale      SurfaceError = 0
+	SurfaceErrorInvalidTransform  SurfaceError = 1
+	SurfaceErrorInvalidSize       SurfaceError = 2
+	SurfaceErrorInvalidOffset     SurfaceError = 3
+	SurfaceErrorDefunctRoleObject SurfaceError = 4
+)
+
+func (e SurfaceError) String() string {
+	switch e {
+	case SurfaceErrorInvalidScale:
+		return "invalid_scale"
+	case SurfaceErrorInvalidTransform:
+		return "invalid_transform"
+	case SurfaceErrorInvalidSize:
+		return "invalid_size"
+	case SurfaceErrorInvalidOffset:
+		return "invalid_offset"
+	case SurfaceErrorDefunctRoleObject:
+		return "defunct_role_object"
+	}
+	return "SurfaceError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e SurfaceError) IsValid() bool {
+	switch e {
+	case SurfaceErrorInvalidScale,
+		SurfaceErrorInvalidTransform,
+		SurfaceErrorInvalidSize,
+		SurfaceErrorInvalidOffset,
+		SurfaceErrorDefunctRoleObject:
+		return true
+	}
+	return false
+}
+
+type SeatCapability uint32
+
 const (
-	ShellSurfaceTransientInactive = 0x1
+	SeatCapabilityPointer  SeatCapability = 1
+	SeatCapabilityKeyboard SeatCapability = 2
+	SeatCapabilityTouch    SeatCapability = 4
 )
 
+func (e SeatCapability) String() string {
+	s := ""
+	if e&SeatCapabilityPointer != 0 {
+		s += "|pointer"
+	}
+	if e&SeatCapabilityKeyboard != 0 {
+		s += "|keyboard"
+	}
+	if e&SeatCapabilityTouch != 0 {
+		s += "|touch"
+	}
+	if rest := e &^ (SeatCapabilityPointer | SeatCapabilityKeyboard | SeatCapabilityTouch); rest != 0 {
+		s += "|0x" + strconv.FormatUint(uint64(rest), 16)
+	}
+	if s == "" {
+		return "0"
+	}
+	return s[1:]
+}
+
+func (e SeatCapability) Has(flags SeatCapability) bool {
+	return e&flags == flags
+}
+
+func (e SeatCapability) IsValid() bool {
+	return e&^(SeatCapabilityPointer|SeatCapabilityKeyboard|SeatCapabilityTouch) == 0
+}
+
+type SeatError uint32
+
 const (
-	ShellSurfaceFullscreenMethodDefault = 0
-	ShellSurfaceFullscreenMethodScale   = 1
-	ShellSurfaceFullscreenMethodDriver  = 2
-	ShellSurfaceFullscreenMethodFill    = 3
+	SeatErrorMissingCapability SeatError = 0
 )
 
+func (e SeatError) String() string {
+	switch e {
+	case SeatErrorMissingCapability:
+		return "missing_capability"
+	}
+	return "SeatError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e SeatError) IsValid() bool {
+	switch e {
+	case SeatErrorMissingCapability:
+		return true
+	}
+	return false
+}
+
+type PointerError uint32
+
 const (
-	SurfaceErrorInvalidScale     = 0
-	SurfaceErrorInvalidTransform = 1
+	PointerErrorRole PointerError = 0
 )
 
+func (e PointerError) String() string {
+	switch e {
+	case PointerErrorRole:
+		return "role"
+	}
+	return "PointerError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e PointerError) IsValid() bool {
+	switch e {
+	case PointerErrorRole:
+		return true
+	}
+	return false
+}
+
+type PointerButtonState uint32
+
 const (
-	SeatCapabilityPointer  = 1
-	SeatCapabilityKeyboard = 2
-	SeatCapabilityTouch    = 4
+	PointerButtonStateReleased PointerButtonState = 0
+	PointerButtonStatePressed  PointerButtonState = 1
 )
 
+func (e PointerButtonState) String() string {
+	switch e {
+	case PointerButtonStateReleased:
+		return "released"
+	case PointerButtonStatePressed:
+		return "pressed"
+	}
+	return "PointerButtonState(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e PointerButtonState) IsValid() bool {
+	switch e {
+	case PointerButtonStateReleased,
+		PointerButtonStatePressed:
+		return true
+	}
+	return false
+}
+
+type PointerAxis uint32
+
 const (
-	PointerErrorRole = 0
+	PointerAxisVerticalScroll   PointerAxis = 0
+	PointerAxisHorizontalScroll PointerAxis = 1
 )
 
+func (e PointerAxis) String() string {
+	switch e {
+	case PointerAxisVerticalScroll:
+		return "vertical_scroll"
+	case PointerAxisHorizontalScroll:
+		return "horizontal_scroll"
+	}
+	return "PointerAxis(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e PointerAxis) IsValid() bool {
+	switch e {
+	case PointerAxisVerticalScroll,
+		PointerAxisHorizontalScroll:
+		return true
+	}
+	return false
+}
+
+type PointerAxisSource uint32
+
 const (
-	PointerButtonStateReleased = 0
-	PointerButtonStatePressed  = 1
+	PointerAxisSourceWheel      PointerAxisSource = 0
+	PointerAxisSourceFinger     PointerAxisSource = 1
+	PointerAxisSourceContinuous PointerAxisSource = 2
+	PointerAxisSourceWheelTilt  PointerAxisSource = 3
 )
 
+func (e PointerAxisSource) String() string {
+	switch e {
+	case PointerAxisSourceWheel:
+		return "wheel"
+	case PointerAxisSourceFinger:
+		return "finger"
+	case PointerAxisSourceContinuous:
+		return "continuous"
+	case PointerAxisSourceWheelTilt:
+		return "wheel_tilt"
+	}
+	return "PointerAxisSource(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e PointerAxisSource) IsValid() bool {
+	switch e {
+	case PointerAxisSourceWheel,
+		PointerAxisSourceFinger,
+		PointerAxisSourceContinuous,
+		PointerAxisSourceWheelTilt:
+		return true
+	}
+	return false
+}
+
+type PointerAxisRelativeDirection uint32
+
 const (
-	PointerAxisVerticalScroll   = 0
-	PointerAxisHorizontalScroll = 1
+	PointerAxisRelativeDirectionIdentical PointerAxisRelativeDirection = 0
+	PointerAxisRelativeDirectionInverted  PointerAxisRelativeDirection = 1
 )
 
+func (e PointerAxisRelativeDirection) String() string {
+	switch e {
+	case PointerAxisRelativeDirectionIdentical:
+		return "identical"
+	case PointerAxisRelativeDirectionInverted:
+		return "inverted"
+	}
+	return "PointerAxisRelativeDirection(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e PointerAxisRelativeDirection) IsValid() bool {
+	switch e {
+	case PointerAxisRelativeDirectionIdentical,
+		PointerAxisRelativeDirectionInverted:
+		return true
+	}
+	return false
+}
+
+type KeyboardKeymapFormat uint32
+
 const (
-	KeyboardKeymapFormatNoKeymap = 0
-	KeyboardKeymapFormatXkbV1    = 1
+	KeyboardKeymapFormatNoKeymap KeyboardKeymapFormat = 0
+	KeyboardKeymapFormatXkbV1    KeyboardKeymapFormat = 1
 )
 
+func (e KeyboardKeymapFormat) String() string {
+	switch e {
+	case KeyboardKeymapFormatNoKeymap:
+		return "no_keymap"
+	case KeyboardKeymapFormatXkbV1:
+		return "xkb_v1"
+	}
+	return "KeyboardKeymapFormat(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e KeyboardKeymapFormat) IsValid() bool {
+	switch e {
+	case KeyboardKeymapFormatNoKeymap,
+		KeyboardKeymapFormatXkbV1:
+		return true
+	}
+	return false
+}
+
+type KeyboardKeyState uint32
+
 const (
-	KeyboardKeyStateReleased = 0
-	KeyboardKeyStatePressed  = 1
+	KeyboardKeyStateReleased KeyboardKeyState = 0
+	KeyboardKeyStatePressed  KeyboardKeyState = 1
 )
 
+func (e KeyboardKeyState) String() string {
+	switch e {
+	case KeyboardKeyStateReleased:
+		return "released"
+	case KeyboardKeyStatePressed:
+		return "pressed"
+	}
+	return "KeyboardKeyState(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e KeyboardKeyState) IsValid() bool {
+	switch e {
+	case KeyboardKeyStateReleased,
+		KeyboardKeyStatePressed:
+		return true
+	}
+	return false
+}
+
+type OutputSubpixel int32
+
 const (
-	OutputSubpixelUnknown       = 0
-	OutputSubpixelNone          = 1
-	OutputSubpixelHorizontalRgb = 2
-	OutputSubpixelHorizontalBgr = 3
-	OutputSubpixelVerticalRgb   = 4
-	OutputSubpixelVerticalBgr   = 5
+	OutputSubpixelUnknown       OutputSubpixel = 0
+	OutputSubpixelNone          OutputSubpixel = 1
+	OutputSubpixelHorizontalRgb OutputSubpixel = 2
+	OutputSubpixelHorizontalBgr OutputSubpixel = 3
+	OutputSubpixelVerticalRgb   OutputSubpixel = 4
+	OutputSubpixelVerticalBgr   OutputSubpixel = 5
 )
 
+func (e OutputSubpixel) String() string {
+	switch e {
+	case OutputSubpixelUnknown:
+		return "unknown"
+	case OutputSubpixelNone:
+		return "none"
+	case OutputSubpixelHorizontalRgb:
+		return "horizontal_rgb"
+	case OutputSubpixelHorizontalBgr:
+		return "horizontal_bgr"
+	case OutputSubpixelVerticalRgb:
+		return "vertical_rgb"
+	case OutputSubpixelVerticalBgr:
+		return "vertical_bgr"
+	}
+	return "OutputSubpixel(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e OutputSubpixel) IsValid() bool {
+	switch e {
+	case OutputSubpixelUnknown,
+		OutputSubpixelNone,
+		OutputSubpixelHorizontalRgb,
+		OutputSubpixelHorizontalBgr,
+		OutputSubpixelVerticalRgb,
+		OutputSubpixelVerticalBgr:
+		return true
+	}
+	return false
+}
+
+type OutputTransform int32
+
 const (
-	OutputTransformNormal     = 0
-	OutputTransform90         = 1
-	OutputTransform180        = 2
-	OutputTransform270        = 3
-	OutputTransformFlipped    = 4
-	OutputTransformFlipped90  = 5
-	OutputTransformFlipped180 = 6
-	OutputTransformFlipped270 = 7
+	OutputTransformNormal     OutputTransform = 0
+	OutputTransform90         OutputTransform = 1
+	OutputTransform180        OutputTransform = 2
+	OutputTransform270        OutputTransform = 3
+	OutputTransformFlipped    OutputTransform = 4
+	OutputTransformFlipped90  OutputTransform = 5
+	OutputTransformFlipped180 OutputTransform = 6
+	OutputTransformFlipped270 OutputTransform = 7
 )
 
+func (e OutputTransform) String() string {
+	switch e {
+	case OutputTransformNormal:
+		return "normal"
+	case OutputTransform90:
+		return "90"
+	case OutputTransform180:
+		return "180"
+	case OutputTransform270:
+		return "270"
+	case OutputTransformFlipped:
+		return "flipped"
+	case OutputTransformFlipped90:
+		return "flipped_90"
+	case OutputTransformFlipped180:
+		return "flipped_180"
+	case OutputTransformFlipped270:
+		return "flipped_270"
+	}
+	return "OutputTransform(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e OutputTransform) IsValid() bool {
+	switch e {
+	case OutputTransformNormal,
+		OutputTransform90,
+		OutputTransform180,
+		OutputTransform270,
+		OutputTransformFlipped,
+		OutputTransformFlipped90,
+		OutputTransformFlipped180,
+		OutputTransformFlipped270:
+		return true
+	}
+	return false
+}
+
+type OutputMode uint32
+
 const (
-	OutputModeCurrent   = 0x1
-	OutputModePreferred = 0x2
+	OutputModeCurrent   OutputMode = 0x1
+	OutputModePreferred OutputMode = 0x2
 )
 
+func (e OutputMode) String() string {
+	s := ""
+	if e&OutputModeCurrent != 0 {
+		s += "|current"
+	}
+	if e&OutputModePreferred != 0 {
+		s += "|preferred"
+	}
+	if rest := e &^ (OutputModeCurrent | OutputModePreferred); rest != 0 {
+		s += "|0x" + strconv.FormatUint(uint64(rest), 16)
+	}
+	if s == "" {
+		return "0"
+	}
+	return s[1:]
+}
+
+func (e OutputMode) Has(flags OutputMode) bool {
+	return e&flags == flags
+}
+
+func (e OutputMode) IsValid() bool {
+	return e&^(OutputModeCurrent|OutputModePreferred) == 0
+}
+
+type SubcompositorError uint32
+
 const (
-	SubcompositorErrorBadSurface = 0
+	SubcompositorErrorBadSurface SubcompositorError = 0
+	SubcompositorErrorBadParent  SubcompositorError = 1
 )
 
+func (e SubcompositorError) String() string {
+	switch e {
+	case SubcompositorErrorBadSurface:
+		return "bad_surface"
+	case SubcompositorErrorBadParent:
+		return "bad_parent"
+	}
+	return "SubcompositorError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e SubcompositorError) IsValid() bool {
+	switch e {
+	case SubcompositorErrorBadSurface,
+		SubcompositorErrorBadParent:
+		return true
+	}
+	return false
+}
+
+type SubsurfaceError uint32
+
 const (
-	SubsurfaceErrorBadSurface = 0
+	SubsurfaceErrorBadSurface SubsurfaceError = 0
 )
 
+func (e SubsurfaceError) String() string {
+	switch e {
+	case SubsurfaceErrorBadSurface:
+		return "bad_surface"
+	}
+	return "SubsurfaceError(" + strconv.Itoa(int(e)) + ")"
+}
+
+func (e SubsurfaceError) IsValid() bool {
+	switch e {
+	case SubsurfaceErrorBadSurface:
+		return true
+	}
+	return false
+}
+
 type DisplayErrorEvent struct {
 	ObjectId Proxy
 	Code     uint32
@@ -198,6 +1308,53 @@ func NewDisplay(c *Connection) *Display {
 	return ret
 }
 
+var displayInterface = Interface{
+	Name:    "wl_display",
+	Version: 1,
+	Requests: []Method{
+		{Name: "sync", Args: []Arg{{Name: "callback", Type: 'n', Interface: "wl_callback"}}},
+		{Name: "get_registry", Args: []Arg{{Name: "registry", Type: 'n', Interface: "wl_registry"}}},
+	},
+	Events: []Method{
+		{Name: "error", Args: []Arg{{Name: "object_id", Type: 'o'}, {Name: "code", Type: 'u'}, {Name: "message", Type: 's'}}},
+		{Name: "delete_id", Args: []Arg{{Name: "id", Type: 'u'}}},
+	},
+	Errors: map[uint32]string{
+		uint32(DisplayErrorInvalidObject):  "DisplayErrorInvalidObject",
+		uint32(DisplayErrorInvalidMethod):  "DisplayErrorInvalidMethod",
+		uint32(DisplayErrorNoMemory):       "DisplayErrorNoMemory",
+		uint32(DisplayErrorImplementation): "DisplayErrorImplementation",
+	},
+}
+
+func (p *Display) Interface() *Interface {
+	return &displayInterface
+}
+
+func (p *Display) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev DisplayErrorEvent
+		if ev.ObjectId, err = m.GetProxy(p.Connection()); err != nil {
+			return err
+		}
+		if ev.Code, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Message, err = m.GetString(); err != nil {
+			return err
+		}
+		return Deliver(p, p.ErrorChan, "Display.ErrorChan", ev)
+	case 1:
+		var ev DisplayDeleteIdEvent
+		if ev.Id, err = m.GetUint32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.DeleteIdChan, "Display.DeleteIdChan", ev)
+	}
+	return nil
+}
+
 func (p *Display) Sync() (*Callback, error) {
 	ret := NewCallback(p.Connection())
 	return ret, p.Connection().SendRequest(p, 0, Proxy(ret))
@@ -232,6 +1389,46 @@ func NewRegistry(c *Connection) *Registry {
 	return ret
 }
 
+var registryInterface = Interface{
+	Name:    "wl_registry",
+	Version: 1,
+	Requests: []Method{
+		{Name: "bind", Args: []Arg{{Name: "name", Type: 'u'}, {Name: "interface", Type: 's'}, {Name: "version", Type: 'u'}, {Name: "id", Type: 'n'}}},
+	},
+	Events: []Method{
+		{Name: "global", Args: []Arg{{Name: "name", Type: 'u'}, {Name: "interface", Type: 's'}, {Name: "version", Type: 'u'}}},
+		{Name: "global_remove", Args: []Arg{{Name: "name", Type: 'u'}}},
+	},
+}
+
+func (p *Registry) Interface() *Interface {
+	return &registryInterface
+}
+
+func (p *Registry) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev RegistryGlobalEvent
+		if ev.Name, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Ifc, err = m.GetString(); err != nil {
+			return err
+		}
+		if ev.Version, err = m.GetUint32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.GlobalChan, "Registry.GlobalChan", ev)
+	case 1:
+		var ev RegistryGlobalRemoveEvent
+		if ev.Name, err = m.GetUint32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.GlobalRemoveChan, "Registry.GlobalRemoveChan", ev)
+	}
+	return nil
+}
+
 func (p *Registry) Bind(name uint32, ifc string, version uint32, id Proxy) error {
 	return p.Connection().SendRequest(p, 0, name, ifc, version, id)
 }
@@ -252,6 +1449,30 @@ func NewCallback(c *Connection) *Callback {
 	return ret
 }
 
+var callbackInterface = Interface{
+	Name:    "wl_callback",
+	Version: 1,
+	Events: []Method{
+		{Name: "done", Args: []Arg{{Name: "callback_data", Type: 'u'}}},
+	},
+}
+
+func (p *Callback) Interface() *Interface {
+	return &callbackInterface
+}
+
+func (p *Callback) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev CallbackDoneEvent
+		if ev.CallbackData, err = m.GetUint32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.DoneChan, "Callback.DoneChan", ev)
+	}
+	return nil
+}
+
 type Compositor struct {
 	BaseProxy
 }
@@ -262,6 +1483,23 @@ func NewCompositor(c *Connection) *Compositor {
 	return ret
 }
 
+var compositorInterface = Interface{
+	Name:    "wl_compositor",
+	Version: 6,
+	Requests: []Method{
+		{Name: "create_surface", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_surface"}}},
+		{Name: "create_region", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_region"}}},
+	},
+}
+
+func (p *Compositor) Interface() *Interface {
+	return &compositorInterface
+}
+
+func (p *Compositor) Dispatch(opcode uint32, m *Message) error {
+	return nil
+}
+
 func (p *Compositor) CreateSurface() (*Surface, error) {
 	ret := NewSurface(p.Connection())
 	return ret, p.Connection().SendRequest(p, 0, Proxy(ret))
@@ -282,9 +1520,27 @@ func NewShmPool(c *Connection) *ShmPool {
 	return ret
 }
 
-func (p *ShmPool) CreateBuffer(offset int32, width int32, height int32, stride int32, format uint32) (*Buffer, error) {
+var shmPoolInterface = Interface{
+	Name:    "wl_shm_pool",
+	Version: 2,
+	Requests: []Method{
+		{Name: "create_buffer", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_buffer"}, {Name: "offset", Type: 'i'}, {Name: "width", Type: 'i'}, {Name: "height", Type: 'i'}, {Name: "stride", Type: 'i'}, {Name: "format", Type: 'u'}}},
+		{Name: "destroy", Destructor: true},
+		{Name: "resize", Args: []Arg{{Name: "size", Type: 'i'}}},
+	},
+}
+
+func (p *ShmPool) Interface() *Interface {
+	return &shmPoolInterface
+}
+
+func (p *ShmPool) Dispatch(opcode uint32, m *Message) error {
+	return nil
+}
+
+func (p *ShmPool) CreateBuffer(offset int32, width int32, height int32, stride int32, format ShmFormat) (*Buffer, error) {
 	ret := NewBuffer(p.Connection())
-	return ret, p.Connection().SendRequest(p, 0, Proxy(ret), offset, width, height, stride, format)
+	return ret, p.Connection().SendRequest(p, 0, Proxy(ret), offset, width, height, stride, uint32(format))
 }
 
 func (p *ShmPool) Destroy() error {
@@ -296,7 +1552,7 @@ func (p *ShmPool) Resize(size int32) error {
 }
 
 type ShmFormatEvent struct {
-	Format uint32
+	Format ShmFormat
 }
 
 type Shm struct {
@@ -311,11 +1567,48 @@ func NewShm(c *Connection) *Shm {
 	return ret
 }
 
+var shmInterface = Interface{
+	Name:    "wl_shm",
+	Version: 2,
+	Requests: []Method{
+		{Name: "create_pool", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_shm_pool"}, {Name: "fd", Type: 'h'}, {Name: "size", Type: 'i'}}},
+		{Name: "release", Since: 2, Destructor: true},
+	},
+	Events: []Method{
+		{Name: "format", Args: []Arg{{Name: "format", Type: 'u'}}},
+	},
+	Errors: map[uint32]string{
+		uint32(ShmErrorInvalidFormat): "ShmErrorInvalidFormat",
+		uint32(ShmErrorInvalidStride): "ShmErrorInvalidStride",
+		uint32(ShmErrorInvalidFd):     "ShmErrorInvalidFd",
+	},
+}
+
+func (p *Shm) Interface() *Interface {
+	return &shmInterface
+}
+
+func (p *Shm) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev ShmFormatEvent
+		if ev.Format, err = GetEnum[ShmFormat](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.FormatChan, "Shm.FormatChan", ev)
+	}
+	return nil
+}
+
 func (p *Shm) CreatePool(fd uintptr, size int32) (*ShmPool, error) {
 	ret := NewShmPool(p.Connection())
 	return ret, p.Connection().SendRequest(p, 0, Proxy(ret), fd, size)
 }
 
+func (p *Shm) Release() error {
+	return p.Connection().SendRequest(p, 1)
+}
+
 type BufferReleaseEvent struct {
 }
 
@@ -331,6 +1624,30 @@ func NewBuffer(c *Connection) *Buffer {
 	return ret
 }
 
+var bufferInterface = Interface{
+	Name:    "wl_buffer",
+	Version: 1,
+	Requests: []Method{
+		{Name: "destroy", Destructor: true},
+	},
+	Events: []Method{
+		{Name: "release"},
+	},
+}
+
+func (p *Buffer) Interface() *Interface {
+	return &bufferInterface
+}
+
+func (p *Buffer) Dispatch(opcode uint32, m *Message) error {
+	switch opcode {
+	case 0:
+		var ev BufferReleaseEvent
+		return Deliver(p, p.ReleaseChan, "Buffer.ReleaseChan", ev)
+	}
+	return nil
+}
+
 func (p *Buffer) Destroy() error {
 	return p.Connection().SendRequest(p, 0)
 }
@@ -339,18 +1656,81 @@ type DataOfferOfferEvent struct {
 	MimeType string
 }
 
+type DataOfferSourceActionsEvent struct {
+	SourceActions DataDeviceManagerDndAction
+}
+
+type DataOfferActionEvent struct {
+	DndAction DataDeviceManagerDndAction
+}
+
 type DataOffer struct {
 	BaseProxy
-	OfferChan chan DataOfferOfferEvent
+	OfferChan         chan DataOfferOfferEvent
+	SourceActionsChan chan DataOfferSourceActionsEvent
+	ActionChan        chan DataOfferActionEvent
 }
 
 func NewDataOffer(c *Connection) *DataOffer {
 	ret := &DataOffer{}
 	ret.OfferChan = make(chan DataOfferOfferEvent, 0)
+	ret.SourceActionsChan = make(chan DataOfferSourceActionsEvent, 0)
+	ret.ActionChan = make(chan DataOfferActionEvent, 0)
 	c.Register(ret)
 	return ret
 }
 
+var dataOfferInterface = Interface{
+	Name:    "wl_data_offer",
+	Version: 3,
+	Requests: []Method{
+		{Name: "accept", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "mime_type", Type: 's', Nullable: true}}},
+		{Name: "receive", Args: []Arg{{Name: "mime_type", Type: 's'}, {Name: "fd", Type: 'h'}}},
+		{Name: "destroy", Destructor: true},
+		{Name: "finish", Since: 3},
+		{Name: "set_actions", Since: 3, Args: []Arg{{Name: "dnd_actions", Type: 'u'}, {Name: "preferred_action", Type: 'u'}}},
+	},
+	Events: []Method{
+		{Name: "offer", Args: []Arg{{Name: "mime_type", Type: 's'}}},
+		{Name: "source_actions", Since: 3, Args: []Arg{{Name: "source_actions", Type: 'u'}}},
+		{Name: "action", Since: 3, Args: []Arg{{Name: "dnd_action", Type: 'u'}}},
+	},
+	Errors: map[uint32]string{
+		uint32(DataOfferErrorInvalidFinish):     "DataOfferErrorInvalidFinish",
+		uint32(DataOfferErrorInvalidActionMask): "DataOfferErrorInvalidActionMask",
+		uint32(DataOfferErrorInvalidAction):     "DataOfferErrorInvalidAction",
+		uint32(DataOfferErrorInvalidOffer):      "DataOfferErrorInvalidOffer",
+	},
+}
+
+func (p *DataOffer) Interface() *Interface {
+	return &dataOfferInterface
+}
+
+func (p *DataOffer) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev DataOfferOfferEvent
+		if ev.MimeType, err = m.GetString(); err != nil {
+			return err
+		}
+		return Deliver(p, p.OfferChan, "DataOffer.OfferChan", ev)
+	case 1:
+		var ev DataOfferSourceActionsEvent
+		if ev.SourceActions, err = GetEnum[DataDeviceManagerDndAction](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.SourceActionsChan, "DataOffer.SourceActionsChan", ev)
+	case 2:
+		var ev DataOfferActionEvent
+		if ev.DndAction, err = GetEnum[DataDeviceManagerDndAction](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.ActionChan, "DataOffer.ActionChan", ev)
+	}
+	return nil
+}
+
 func (p *DataOffer) Accept(serial uint32, mimeType string) error {
 	return p.Connection().SendRequest(p, 0, serial, mimeType)
 }
@@ -363,6 +1743,14 @@ func (p *DataOffer) Destroy() error {
 	return p.Connection().SendRequest(p, 2)
 }
 
+func (p *DataOffer) Finish() error {
+	return p.Connection().SendRequest(p, 3)
+}
+
+func (p *DataOffer) SetActions(dndActions DataDeviceManagerDndAction, preferredAction DataDeviceManagerDndAction) error {
+	return p.Connection().SendRequest(p, 4, uint32(dndActions), uint32(preferredAction))
+}
+
 type DataSourceTargetEvent struct {
 	MimeType string
 }
@@ -375,11 +1763,24 @@ type DataSourceSendEvent struct {
 type DataSourceCancelledEvent struct {
 }
 
+type DataSourceDndDropPerformedEvent struct {
+}
+
+type DataSourceDndFinishedEvent struct {
+}
+
+type DataSourceActionEvent struct {
+	DndAction DataDeviceManagerDndAction
+}
+
 type DataSource struct {
 	BaseProxy
-	TargetChan    chan DataSourceTargetEvent
-	SendChan      chan DataSourceSendEvent
-	CancelledChan chan DataSourceCancelledEvent
+	TargetChan           chan DataSourceTargetEvent
+	SendChan             chan DataSourceSendEvent
+	CancelledChan        chan DataSourceCancelledEvent
+	DndDropPerformedChan chan DataSourceDndDropPerformedEvent
+	DndFinishedChan      chan DataSourceDndFinishedEvent
+	ActionChan           chan DataSourceActionEvent
 }
 
 func NewDataSource(c *Connection) *DataSource {
@@ -387,10 +1788,75 @@ func NewDataSource(c *Connection) *DataSource {
 	ret.TargetChan = make(chan DataSourceTargetEvent, 0)
 	ret.SendChan = make(chan DataSourceSendEvent, 0)
 	ret.CancelledChan = make(chan DataSourceCancelledEvent, 0)
+	ret.DndDropPerformedChan = make(chan DataSourceDndDropPerformedEvent, 0)
+	ret.DndFinishedChan = make(chan DataSourceDndFinishedEvent, 0)
+	ret.ActionChan = make(chan DataSourceActionEvent, 0)
 	c.Register(ret)
 	return ret
 }
 
+var dataSourceInterface = Interface{
+	Name:    "wl_data_source",
+	Version: 3,
+	Requests: []Method{
+		{Name: "offer", Args: []Arg{{Name: "mime_type", Type: 's'}}},
+		{Name: "destroy", Destructor: true},
+		{Name: "set_actions", Since: 3, Args: []Arg{{Name: "dnd_actions", Type: 'u'}}},
+	},
+	Events: []Method{
+		{Name: "target", Args: []Arg{{Name: "mime_type", Type: 's', Nullable: true}}},
+		{Name: "send", Args: []Arg{{Name: "mime_type", Type: 's'}, {Name: "fd", Type: 'h'}}},
+		{Name: "cancelled"},
+		{Name: "dnd_drop_performed", Since: 3},
+		{Name: "dnd_finished", Since: 3},
+		{Name: "action", Since: 3, Args: []Arg{{Name: "dnd_action", Type: 'u'}}},
+	},
+	Errors: map[uint32]string{
+		uint32(DataSourceErrorInvalidActionMask): "DataSourceErrorInvalidActionMask",
+		uint32(DataSourceErrorInvalidSource):     "DataSourceErrorInvalidSource",
+	},
+}
+
+func (p *DataSource) Interface() *Interface {
+	return &dataSourceInterface
+}
+
+func (p *DataSource) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev DataSourceTargetEvent
+		if ev.MimeType, err = m.GetString(); err != nil {
+			return err
+		}
+		return Deliver(p, p.TargetChan, "DataSource.TargetChan", ev)
+	case 1:
+		var ev DataSourceSendEvent
+		if ev.MimeType, err = m.GetString(); err != nil {
+			return err
+		}
+		if ev.Fd, err = m.GetFD(); err != nil {
+			return err
+		}
+		return Deliver(p, p.SendChan, "DataSource.SendChan", ev)
+	case 2:
+		var ev DataSourceCancelledEvent
+		return Deliver(p, p.CancelledChan, "DataSource.CancelledChan", ev)
+	case 3:
+		var ev DataSourceDndDropPerformedEvent
+		return Deliver(p, p.DndDropPerformedChan, "DataSource.DndDropPerformedChan", ev)
+	case 4:
+		var ev DataSourceDndFinishedEvent
+		return Deliver(p, p.DndFinishedChan, "DataSource.DndFinishedChan", ev)
+	case 5:
+		var ev DataSourceActionEvent
+		if ev.DndAction, err = GetEnum[DataDeviceManagerDndAction](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.ActionChan, "DataSource.ActionChan", ev)
+	}
+	return nil
+}
+
 func (p *DataSource) Offer(mimeType string) error {
 	return p.Connection().SendRequest(p, 0, mimeType)
 }
@@ -399,6 +1865,10 @@ func (p *DataSource) Destroy() error {
 	return p.Connection().SendRequest(p, 1)
 }
 
+func (p *DataSource) SetActions(dndActions DataDeviceManagerDndAction) error {
+	return p.Connection().SendRequest(p, 2, uint32(dndActions))
+}
+
 type DataDeviceDataOfferEvent struct {
 	Id *DataOffer
 }
@@ -406,8 +1876,8 @@ type DataDeviceDataOfferEvent struct {
 type DataDeviceEnterEvent struct {
 	Serial  uint32
 	Surface *Surface
-	X       float32
-	Y       float32
+	X       Fixed
+	Y       Fixed
 	Id      *DataOffer
 }
 
@@ -416,8 +1886,8 @@ type DataDeviceLeaveEvent struct {
 
 type DataDeviceMotionEvent struct {
 	Time uint32
-	X    float32
-	Y    float32
+	X    Fixed
+	Y    Fixed
 }
 
 type DataDeviceDropEvent struct {
@@ -449,12 +1919,93 @@ func NewDataDevice(c *Connection) *DataDevice {
 	return ret
 }
 
+var dataDeviceInterface = Interface{
+	Name:    "wl_data_device",
+	Version: 3,
+	Requests: []Method{
+		{Name: "start_drag", Args: []Arg{{Name: "source", Type: 'o', Interface: "wl_data_source", Nullable: true}, {Name: "origin", Type: 'o', Interface: "wl_surface"}, {Name: "icon", Type: 'o', Interface: "wl_surface", Nullable: true}, {Name: "serial", Type: 'u'}}},
+		{Name: "set_selection", Args: []Arg{{Name: "source", Type: 'o', Interface: "wl_data_source", Nullable: true}, {Name: "serial", Type: 'u'}}},
+		{Name: "release", Since: 2, Destructor: true},
+	},
+	Events: []Method{
+		{Name: "data_offer", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_data_offer"}}},
+		{Name: "enter", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "surface", Type: 'o', Interface: "wl_surface"}, {Name: "x", Type: 'f'}, {Name: "y", Type: 'f'}, {Name: "id", Type: 'o', Interface: "wl_data_offer", Nullable: true}}},
+		{Name: "leave"},
+		{Name: "motion", Args: []Arg{{Name: "time", Type: 'u'}, {Name: "x", Type: 'f'}, {Name: "y", Type: 'f'}}},
+		{Name: "drop"},
+		{Name: "selection", Args: []Arg{{Name: "id", Type: 'o', Interface: "wl_data_offer", Nullable: true}}},
+	},
+	Errors: map[uint32]string{
+		uint32(DataDeviceErrorRole):       "DataDeviceErrorRole",
+		uint32(DataDeviceErrorUsedSource): "DataDeviceErrorUsedSource",
+	},
+}
+
+func (p *DataDevice) Interface() *Interface {
+	return &dataDeviceInterface
+}
+
+func (p *DataDevice) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev DataDeviceDataOfferEvent
+		ev.Id = NewDataOffer(p.Connection())
+		if err = m.GetNewId(p, ev.Id); err != nil {
+			return err
+		}
+		return Deliver(p, p.DataOfferChan, "DataDevice.DataOfferChan", ev)
+	case 1:
+		var ev DataDeviceEnterEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Surface, err = GetObject[*Surface](p.Connection(), m); err != nil {
+			return err
+		}
+		if ev.X, err = m.GetFixed(); err != nil {
+			return err
+		}
+		if ev.Y, err = m.GetFixed(); err != nil {
+			return err
+		}
+		if ev.Id, err = GetObject[*DataOffer](p.Connection(), m); err != nil {
+			return err
+		}
+		return Deliver(p, p.EnterChan, "DataDevice.EnterChan", ev)
+	case 2:
+		var ev DataDeviceLeaveEvent
+		return Deliver(p, p.LeaveChan, "DataDevice.LeaveChan", ev)
+	case 3:
+		var ev DataDeviceMotionEvent
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.X, err = m.GetFixed(); err != nil {
+			return err
+		}
+		if ev.Y, err = m.GetFixed(); err != nil {
+			return err
+		}
+		return Deliver(p, p.MotionChan, "DataDevice.MotionChan", ev)
+	case 4:
+		var ev DataDeviceDropEvent
+		return Deliver(p, p.DropChan, "DataDevice.DropChan", ev)
+	case 5:
+		var ev DataDeviceSelectionEvent
+		if ev.Id, err = GetObject[*DataOffer](p.Connection(), m); err != nil {
+			return err
+		}
+		return Deliver(p, p.SelectionChan, "DataDevice.SelectionChan", ev)
+	}
+	return nil
+}
+
 func (p *DataDevice) StartDrag(source *DataSource, origin *Surface, icon *Surface, serial uint32) error {
-	return p.Connection().SendRequest(p, 0, source, origin, icon, serial)
+	return p.Connection().SendRequest(p, 0, ObjectArg(source), ObjectArg(origin), ObjectArg(icon), serial)
 }
 
 func (p *DataDevice) SetSelection(source *DataSource, serial uint32) error {
-	return p.Connection().SendRequest(p, 1, source, serial)
+	return p.Connection().SendRequest(p, 1, ObjectArg(source), serial)
 }
 
 func (p *DataDevice) Release() error {
@@ -471,6 +2022,23 @@ func NewDataDeviceManager(c *Connection) *DataDeviceManager {
 	return ret
 }
 
+var dataDeviceManagerInterface = Interface{
+	Name:    "wl_data_device_manager",
+	Version: 3,
+	Requests: []Method{
+		{Name: "create_data_source", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_data_source"}}},
+		{Name: "get_data_device", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_data_device"}, {Name: "seat", Type: 'o', Interface: "wl_seat"}}},
+	},
+}
+
+func (p *DataDeviceManager) Interface() *Interface {
+	return &dataDeviceManagerInterface
+}
+
+func (p *DataDeviceManager) Dispatch(opcode uint32, m *Message) error {
+	return nil
+}
+
 func (p *DataDeviceManager) CreateDataSource() (*DataSource, error) {
 	ret := NewDataSource(p.Connection())
 	return ret, p.Connection().SendRequest(p, 0, Proxy(ret))
@@ -478,7 +2046,7 @@ func (p *DataDeviceManager) CreateDataSource() (*DataSource, error) {
 
 func (p *DataDeviceManager) GetDataDevice(seat *Seat) (*DataDevice, error) {
 	ret := NewDataDevice(p.Connection())
-	return ret, p.Connection().SendRequest(p, 1, Proxy(ret), seat)
+	return ret, p.Connection().SendRequest(p, 1, Proxy(ret), ObjectArg(seat))
 }
 
 type Shell struct {
@@ -491,9 +2059,28 @@ func NewShell(c *Connection) *Shell {
 	return ret
 }
 
+var shellInterface = Interface{
+	Name:    "wl_shell",
+	Version: 1,
+	Requests: []Method{
+		{Name: "get_shell_surface", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_shell_surface"}, {Name: "surface", Type: 'o', Interface: "wl_surface"}}},
+	},
+	Errors: map[uint32]string{
+		uint32(ShellErrorRole): "ShellErrorRole",
+	},
+}
+
+func (p *Shell) Interface() *Interface {
+	return &shellInterface
+}
+
+func (p *Shell) Dispatch(opcode uint32, m *Message) error {
+	return nil
+}
+
 func (p *Shell) GetShellSurface(surface *Surface) (*ShellSurface, error) {
 	ret := NewShellSurface(p.Connection())
-	return ret, p.Connection().SendRequest(p, 0, Proxy(ret), surface)
+	return ret, p.Connection().SendRequest(p, 0, Proxy(ret), ObjectArg(surface))
 }
 
 type ShellSurfacePingEvent struct {
@@ -501,7 +2088,7 @@ type ShellSurfacePingEvent struct {
 }
 
 type ShellSurfaceConfigureEvent struct {
-	Edges  uint32
+	Edges  ShellSurfaceResize
 	Width  int32
 	Height int32
 }
@@ -525,36 +2112,89 @@ func NewShellSurface(c *Connection) *ShellSurface {
 	return ret
 }
 
+var shellSurfaceInterface = Interface{
+	Name:    "wl_shell_surface",
+	Version: 1,
+	Requests: []Method{
+		{Name: "pong", Args: []Arg{{Name: "serial", Type: 'u'}}},
+		{Name: "move", Args: []Arg{{Name: "seat", Type: 'o', Interface: "wl_seat"}, {Name: "serial", Type: 'u'}}},
+		{Name: "resize", Args: []Arg{{Name: "seat", Type: 'o', Interface: "wl_seat"}, {Name: "serial", Type: 'u'}, {Name: "edges", Type: 'u'}}},
+		{Name: "set_toplevel"},
+		{Name: "set_transient", Args: []Arg{{Name: "parent", Type: 'o', Interface: "wl_surface"}, {Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}, {Name: "flags", Type: 'u'}}},
+		{Name: "set_fullscreen", Args: []Arg{{Name: "method", Type: 'u'}, {Name: "framerate", Type: 'u'}, {Name: "output", Type: 'o', Interface: "wl_output", Nullable: true}}},
+		{Name: "set_popup", Args: []Arg{{Name: "seat", Type: 'o', Interface: "wl_seat"}, {Name: "serial", Type: 'u'}, {Name: "parent", Type: 'o', Interface: "wl_surface"}, {Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}, {Name: "flags", Type: 'u'}}},
+		{Name: "set_maximized", Args: []Arg{{Name: "output", Type: 'o', Interface: "wl_output", Nullable: true}}},
+		{Name: "set_title", Args: []Arg{{Name: "title", Type: 's'}}},
+		{Name: "set_class", Args: []Arg{{Name: "class_", Type: 's'}}},
+	},
+	Events: []Method{
+		{Name: "ping", Args: []Arg{{Name: "serial", Type: 'u'}}},
+		{Name: "configure", Args: []Arg{{Name: "edges", Type: 'u'}, {Name: "width", Type: 'i'}, {Name: "height", Type: 'i'}}},
+		{Name: "popup_done"},
+	},
+}
+
+func (p *ShellSurface) Interface() *Interface {
+	return &shellSurfaceInterface
+}
+
+func (p *ShellSurface) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev ShellSurfacePingEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.PingChan, "ShellSurface.PingChan", ev)
+	case 1:
+		var ev ShellSurfaceConfigureEvent
+		if ev.Edges, err = GetEnum[ShellSurfaceResize](m); err != nil {
+			return err
+		}
+		if ev.Width, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.Height, err = m.GetInt32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.ConfigureChan, "ShellSurface.ConfigureChan", ev)
+	case 2:
+		var ev ShellSurfacePopupDoneEvent
+		return Deliver(p, p.PopupDoneChan, "ShellSurface.PopupDoneChan", ev)
+	}
+	return nil
+}
+
 func (p *ShellSurface) Pong(serial uint32) error {
 	return p.Connection().SendRequest(p, 0, serial)
 }
 
 func (p *ShellSurface) Move(seat *Seat, serial uint32) error {
-	return p.Connection().SendRequest(p, 1, seat, serial)
+	return p.Connection().SendRequest(p, 1, ObjectArg(seat), serial)
 }
 
-func (p *ShellSurface) Resize(seat *Seat, serial uint32, edges uint32) error {
-	return p.Connection().SendRequest(p, 2, seat, serial, edges)
+func (p *ShellSurface) Resize(seat *Seat, serial uint32, edges ShellSurfaceResize) error {
+	return p.Connection().SendRequest(p, 2, ObjectArg(seat), serial, uint32(edges))
 }
 
 func (p *ShellSurface) SetToplevel() error {
 	return p.Connection().SendRequest(p, 3)
 }
 
-func (p *ShellSurface) SetTransient(parent *Surface, x int32, y int32, flags uint32) error {
-	return p.Connection().SendRequest(p, 4, parent, x, y, flags)
+func (p *ShellSurface) SetTransient(parent *Surface, x int32, y int32, flags ShellSurfaceTransient) error {
+	return p.Connection().SendRequest(p, 4, ObjectArg(parent), x, y, uint32(flags))
 }
 
-func (p *ShellSurface) SetFullscreen(method uint32, framerate uint32, output *Output) error {
-	return p.Connection().SendRequest(p, 5, method, framerate, output)
+func (p *ShellSurface) SetFullscreen(method ShellSurfaceFullscreenMethod, framerate uint32, output *Output) error {
+	return p.Connection().SendRequest(p, 5, uint32(method), framerate, ObjectArg(output))
 }
 
-func (p *ShellSurface) SetPopup(seat *Seat, serial uint32, parent *Surface, x int32, y int32, flags uint32) error {
-	return p.Connection().SendRequest(p, 6, seat, serial, parent, x, y, flags)
+func (p *ShellSurface) SetPopup(seat *Seat, serial uint32, parent *Surface, x int32, y int32, flags ShellSurfaceTransient) error {
+	return p.Connection().SendRequest(p, 6, ObjectArg(seat), serial, ObjectArg(parent), x, y, uint32(flags))
 }
 
 func (p *ShellSurface) SetMaximized(output *Output) error {
-	return p.Connection().SendRequest(p, 7, output)
+	return p.Connection().SendRequest(p, 7, ObjectArg(output))
 }
 
 func (p *ShellSurface) SetTitle(title string) error {
@@ -573,26 +2213,103 @@ type SurfaceLeaveEvent struct {
 	Output *Output
 }
 
+type SurfacePreferredBufferScaleEvent struct {
+	Factor int32
+}
+
+type SurfacePreferredBufferTransformEvent struct {
+	Transform OutputTransform
+}
+
 type Surface struct {
 	BaseProxy
-	EnterChan chan SurfaceEnterEvent
-	LeaveChan chan SurfaceLeaveEvent
+	EnterChan                    chan SurfaceEnterEvent
+	LeaveChan                    chan SurfaceLeaveEvent
+	PreferredBufferScaleChan     chan SurfacePreferredBufferScaleEvent
+	PreferredBufferTransformChan chan SurfacePreferredBufferTransformEvent
 }
 
 func NewSurface(c *Connection) *Surface {
 	ret := &Surface{}
 	ret.EnterChan = make(chan SurfaceEnterEvent, 0)
 	ret.LeaveChan = make(chan SurfaceLeaveEvent, 0)
+	ret.PreferredBufferScaleChan = make(chan SurfacePreferredBufferScaleEvent, 0)
+	ret.PreferredBufferTransformChan = make(chan SurfacePreferredBufferTransformEvent, 0)
 	c.Register(ret)
 	return ret
 }
 
+var surfaceInterface = Interface{
+	Name:    "wl_surface",
+	Version: 6,
+	Requests: []Method{
+		{Name: "destroy", Destructor: true},
+		{Name: "attach", Args: []Arg{{Name: "buffer", Type: 'o', Interface: "wl_buffer", Nullable: true}, {Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}}},
+		{Name: "damage", Args: []Arg{{Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}, {Name: "width", Type: 'i'}, {Name: "height", Type: 'i'}}},
+		{Name: "frame", Args: []Arg{{Name: "callback", Type: 'n', Interface: "wl_callback"}}},
+		{Name: "set_opaque_region", Args: []Arg{{Name: "region", Type: 'o', Interface: "wl_region", Nullable: true}}},
+		{Name: "set_input_region", Args: []Arg{{Name: "region", Type: 'o', Interface: "wl_region", Nullable: true}}},
+		{Name: "commit"},
+		{Name: "set_buffer_transform", Since: 2, Args: []Arg{{Name: "transform", Type: 'i'}}},
+		{Name: "set_buffer_scale", Since: 3, Args: []Arg{{Name: "scale", Type: 'i'}}},
+		{Name: "damage_buffer", Since: 4, Args: []Arg{{Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}, {Name: "width", Type: 'i'}, {Name: "height", Type: 'i'}}},
+		{Name: "offset", Since: 5, Args: []Arg{{Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}}},
+	},
+	Events: []Method{
+		{Name: "enter", Args: []Arg{{Name: "output", Type: 'o', Interface: "wl_output"}}},
+		{Name: "leave", Args: []Arg{{Name: "output", Type: 'o', Interface: "wl_output"}}},
+		{Name: "preferred_buffer_scale", Since: 6, Args: []Arg{{Name: "factor", Type: 'i'}}},
+		{Name: "preferred_buffer_transform", Since: 6, Args: []Arg{{Name: "transform", Type: 'u'}}},
+	},
+	Errors: map[uint32]string{
+		uint32(SurfaceErrorInvalidScale):      "SurfaceErrorInvalidScale",
+		uint32(SurfaceErrorInvalidTransform):  "SurfaceErrorInvalidTransform",
+		uint32(SurfaceErrorInvalidSize):       "SurfaceErrorInvalidSize",
+		uint32(SurfaceErrorInvalidOffset):     "SurfaceErrorInvalidOffset",
+		uint32(SurfaceErrorDefunctRoleObject): "SurfaceErrorDefunctRoleObject",
+	},
+}
+
+func (p *Surface) Interface() *Interface {
+	return &surfaceInterface
+}
+
+func (p *Surface) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev SurfaceEnterEvent
+		if ev.Output, err = GetObject[*Output](p.Connection(), m); err != nil {
+			return err
+		}
+		return Deliver(p, p.EnterChan, "Surface.EnterChan", ev)
+	case 1:
+		var ev SurfaceLeaveEvent
+		if ev.Output, err = GetObject[*Output](p.Connection(), m); err != nil {
+			return err
+		}
+		return Deliver(p, p.LeaveChan, "Surface.LeaveChan", ev)
+	case 2:
+		var ev SurfacePreferredBufferScaleEvent
+		if ev.Factor, err = m.GetInt32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.PreferredBufferScaleChan, "Surface.PreferredBufferScaleChan", ev)
+	case 3:
+		var ev SurfacePreferredBufferTransformEvent
+		if ev.Transform, err = GetEnum[OutputTransform](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.PreferredBufferTransformChan, "Surface.PreferredBufferTransformChan", ev)
+	}
+	return nil
+}
+
 func (p *Surface) Destroy() error {
 	return p.Connection().SendRequest(p, 0)
 }
 
 func (p *Surface) Attach(buffer *Buffer, x int32, y int32) error {
-	return p.Connection().SendRequest(p, 1, buffer, x, y)
+	return p.Connection().SendRequest(p, 1, ObjectArg(buffer), x, y)
 }
 
 func (p *Surface) Damage(x int32, y int32, width int32, height int32) error {
@@ -605,27 +2322,35 @@ func (p *Surface) Frame() (*Callback, error) {
 }
 
 func (p *Surface) SetOpaqueRegion(region *Region) error {
-	return p.Connection().SendRequest(p, 4, region)
+	return p.Connection().SendRequest(p, 4, ObjectArg(region))
 }
 
 func (p *Surface) SetInputRegion(region *Region) error {
-	return p.Connection().SendRequest(p, 5, region)
+	return p.Connection().SendRequest(p, 5, ObjectArg(region))
 }
 
 func (p *Surface) Commit() error {
 	return p.Connection().SendRequest(p, 6)
 }
 
-func (p *Surface) SetBufferTransform(transform int32) error {
-	return p.Connection().SendRequest(p, 7, transform)
+func (p *Surface) SetBufferTransform(transform OutputTransform) error {
+	return p.Connection().SendRequest(p, 7, int32(transform))
 }
 
 func (p *Surface) SetBufferScale(scale int32) error {
 	return p.Connection().SendRequest(p, 8, scale)
 }
 
+func (p *Surface) DamageBuffer(x int32, y int32, width int32, height int32) error {
+	return p.Connection().SendRequest(p, 9, x, y, width, height)
+}
+
+func (p *Surface) Offset(x int32, y int32) error {
+	return p.Connection().SendRequest(p, 10, x, y)
+}
+
 type SeatCapabilitiesEvent struct {
-	Capabilities uint32
+	Capabilities SeatCapability
 }
 
 type SeatNameEvent struct {
@@ -646,6 +2371,46 @@ func NewSeat(c *Connection) *Seat {
 	return ret
 }
 
+var seatInterface = Interface{
+	Name:    "wl_seat",
+	Version: 9,
+	Requests: []Method{
+		{Name: "get_pointer", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_pointer"}}},
+		{Name: "get_keyboard", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_keyboard"}}},
+		{Name: "get_touch", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_touch"}}},
+		{Name: "release", Since: 5, Destructor: true},
+	},
+	Events: []Method{
+		{Name: "capabilities", Args: []Arg{{Name: "capabilities", Type: 'u'}}},
+		{Name: "name", Since: 2, Args: []Arg{{Name: "name", Type: 's'}}},
+	},
+	Errors: map[uint32]string{
+		uint32(SeatErrorMissingCapability): "SeatErrorMissingCapability",
+	},
+}
+
+func (p *Seat) Interface() *Interface {
+	return &seatInterface
+}
+
+func (p *Seat) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev SeatCapabilitiesEvent
+		if ev.Capabilities, err = GetEnum[SeatCapability](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.CapabilitiesChan, "Seat.CapabilitiesChan", ev)
+	case 1:
+		var ev SeatNameEvent
+		if ev.Name, err = m.GetString(); err != nil {
+			return err
+		}
+		return Deliver(p, p.NameChan, "Seat.NameChan", ev)
+	}
+	return nil
+}
+
 func (p *Seat) GetPointer() (*Pointer, error) {
 	ret := NewPointer(p.Connection())
 	return ret, p.Connection().SendRequest(p, 0, Proxy(ret))
@@ -661,11 +2426,15 @@ func (p *Seat) GetTouch() (*Touch, error) {
 	return ret, p.Connection().SendRequest(p, 2, Proxy(ret))
 }
 
+func (p *Seat) Release() error {
+	return p.Connection().SendRequest(p, 3)
+}
+
 type PointerEnterEvent struct {
 	Serial   uint32
 	Surface  *Surface
-	SurfaceX float32
-	SurfaceY float32
+	SurfaceX Fixed
+	SurfaceY Fixed
 }
 
 type PointerLeaveEvent struct {
@@ -675,30 +2444,63 @@ type PointerLeaveEvent struct {
 
 type PointerMotionEvent struct {
 	Time     uint32
-	SurfaceX float32
-	SurfaceY float32
+	SurfaceX Fixed
+	SurfaceY Fixed
 }
 
 type PointerButtonEvent struct {
 	Serial uint32
 	Time   uint32
 	Button uint32
-	State  uint32
+	State  PointerButtonState
 }
 
 type PointerAxisEvent struct {
 	Time  uint32
-	Axis  uint32
-	Value float32
+	Axis  PointerAxis
+	Value Fixed
+}
+
+type PointerFrameEvent struct {
+}
+
+type PointerAxisSourceEvent struct {
+	AxisSource PointerAxisSource
+}
+
+type PointerAxisStopEvent struct {
+	Time uint32
+	Axis PointerAxis
+}
+
+type PointerAxisDiscreteEvent struct {
+	Axis     PointerAxis
+	Discrete int32
+}
+
+type PointerAxisValue120Event struct {
+	Axis     PointerAxis
+	Value120 int32
+}
+
+type PointerAxisRelativeDirectionEvent struct {
+	Axis      PointerAxis
+	Direction PointerAxisRelativeDirection
 }
 
 type Pointer struct {
 	BaseProxy
-	EnterChan  chan PointerEnterEvent
-	LeaveChan  chan PointerLeaveEvent
-	MotionChan chan PointerMotionEvent
-	ButtonChan chan PointerButtonEvent
-	AxisChan   chan PointerAxisEvent
+	EnterChan                 chan PointerEnterEvent
+	LeaveChan                 chan PointerLeaveEvent
+	MotionChan                chan PointerMotionEvent
+	ButtonChan                chan PointerButtonEvent
+	AxisChan                  chan PointerAxisEvent
+	FrameChan                 chan PointerFrameEvent
+	AxisSourceChan            chan PointerAxisSourceEvent
+	AxisStopChan              chan PointerAxisStopEvent
+	AxisDiscreteChan          chan PointerAxisDiscreteEvent
+	AxisValue120Chan          chan PointerAxisValue120Event
+	AxisRelativeDirectionChan chan PointerAxisRelativeDirectionEvent
 }
 
 func NewPointer(c *Connection) *Pointer {
@@ -708,12 +2510,161 @@ func NewPointer(c *Connection) *Pointer {
 	ret.MotionChan = make(chan PointerMotionEvent, 0)
 	ret.ButtonChan = make(chan PointerButtonEvent, 0)
 	ret.AxisChan = make(chan PointerAxisEvent, 0)
+	ret.FrameChan = make(chan PointerFrameEvent, 0)
+	ret.AxisSourceChan = make(chan PointerAxisSourceEvent, 0)
+	ret.AxisStopChan = make(chan PointerAxisStopEvent, 0)
+	ret.AxisDiscreteChan = make(chan PointerAxisDiscreteEvent, 0)
+	ret.AxisValue120Chan = make(chan PointerAxisValue120Event, 0)
+	ret.AxisRelativeDirectionChan = make(chan PointerAxisRelativeDirectionEvent, 0)
 	c.Register(ret)
 	return ret
 }
 
+var pointerInterface = Interface{
+	Name:    "wl_pointer",
+	Version: 9,
+	Requests: []Method{
+		{Name: "set_cursor", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "surface", Type: 'o', Interface: "wl_surface", Nullable: true}, {Name: "hotspot_x", Type: 'i'}, {Name: "hotspot_y", Type: 'i'}}},
+		{Name: "release", Since: 3, Destructor: true},
+	},
+	Events: []Method{
+		{Name: "enter", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "surface", Type: 'o', Interface: "wl_surface"}, {Name: "surface_x", Type: 'f'}, {Name: "surface_y", Type: 'f'}}},
+		{Name: "leave", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "surface", Type: 'o', Interface: "wl_surface"}}},
+		{Name: "motion", Args: []Arg{{Name: "time", Type: 'u'}, {Name: "surface_x", Type: 'f'}, {Name: "surface_y", Type: 'f'}}},
+		{Name: "button", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "time", Type: 'u'}, {Name: "button", Type: 'u'}, {Name: "state", Type: 'u'}}},
+		{Name: "axis", Args: []Arg{{Name: "time", Type: 'u'}, {Name: "axis", Type: 'u'}, {Name: "value", Type: 'f'}}},
+		{Name: "frame", Since: 5},
+		{Name: "axis_source", Since: 5, Args: []Arg{{Name: "axis_source", Type: 'u'}}},
+		{Name: "axis_stop", Since: 5, Args: []Arg{{Name: "time", Type: 'u'}, {Name: "axis", Type: 'u'}}},
+		{Name: "axis_discrete", Since: 5, Args: []Arg{{Name: "axis", Type: 'u'}, {Name: "discrete", Type: 'i'}}},
+		{Name: "axis_value120", Since: 8, Args: []Arg{{Name: "axis", Type: 'u'}, {Name: "value120", Type: 'i'}}},
+		{Name: "axis_relative_direction", Since: 9, Args: []Arg{{Name: "axis", Type: 'u'}, {Name: "direction", Type: 'u'}}},
+	},
+	Errors: map[uint32]string{
+		uint32(PointerErrorRole): "PointerErrorRole",
+	},
+}
+
+func (p *Pointer) Interface() *Interface {
+	return &pointerInterface
+}
+
+func (p *Pointer) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev PointerEnterEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Surface, err = GetObject[*Surface](p.Connection(), m); err != nil {
+			return err
+		}
+		if ev.SurfaceX, err = m.GetFixed(); err != nil {
+			return err
+		}
+		if ev.SurfaceY, err = m.GetFixed(); err != nil {
+			return err
+		}
+		return Deliver(p, p.EnterChan, "Pointer.EnterChan", ev)
+	case 1:
+		var ev PointerLeaveEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Surface, err = GetObject[*Surface](p.Connection(), m); err != nil {
+			return err
+		}
+		return Deliver(p, p.LeaveChan, "Pointer.LeaveChan", ev)
+	case 2:
+		var ev PointerMotionEvent
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.SurfaceX, err = m.GetFixed(); err != nil {
+			return err
+		}
+		if ev.SurfaceY, err = m.GetFixed(); err != nil {
+			return err
+		}
+		return Deliver(p, p.MotionChan, "Pointer.MotionChan", ev)
+	case 3:
+		var ev PointerButtonEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Button, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.State, err = GetEnum[PointerButtonState](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.ButtonChan, "Pointer.ButtonChan", ev)
+	case 4:
+		var ev PointerAxisEvent
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Axis, err = GetEnum[PointerAxis](m); err != nil {
+			return err
+		}
+		if ev.Value, err = m.GetFixed(); err != nil {
+			return err
+		}
+		return Deliver(p, p.AxisChan, "Pointer.AxisChan", ev)
+	case 5:
+		var ev PointerFrameEvent
+		return Deliver(p, p.FrameChan, "Pointer.FrameChan", ev)
+	case 6:
+		var ev PointerAxisSourceEvent
+		if ev.AxisSource, err = GetEnum[PointerAxisSource](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.AxisSourceChan, "Pointer.AxisSourceChan", ev)
+	case 7:
+		var ev PointerAxisStopEvent
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Axis, err = GetEnum[PointerAxis](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.AxisStopChan, "Pointer.AxisStopChan", ev)
+	case 8:
+		var ev PointerAxisDiscreteEvent
+		if ev.Axis, err = GetEnum[PointerAxis](m); err != nil {
+			return err
+		}
+		if ev.Discrete, err = m.GetInt32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.AxisDiscreteChan, "Pointer.AxisDiscreteChan", ev)
+	case 9:
+		var ev PointerAxisValue120Event
+		if ev.Axis, err = GetEnum[PointerAxis](m); err != nil {
+			return err
+		}
+		if ev.Value120, err = m.GetInt32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.AxisValue120Chan, "Pointer.AxisValue120Chan", ev)
+	case 10:
+		var ev PointerAxisRelativeDirectionEvent
+		if ev.Axis, err = GetEnum[PointerAxis](m); err != nil {
+			return err
+		}
+		if ev.Direction, err = GetEnum[PointerAxisRelativeDirection](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.AxisRelativeDirectionChan, "Pointer.AxisRelativeDirectionChan", ev)
+	}
+	return nil
+}
+
 func (p *Pointer) SetCursor(serial uint32, surface *Surface, hotspotX int32, hotspotY int32) error {
-	return p.Connection().SendRequest(p, 0, serial, surface, hotspotX, hotspotY)
+	return p.Connection().SendRequest(p, 0, serial, ObjectArg(surface), hotspotX, hotspotY)
 }
 
 func (p *Pointer) Release() error {
@@ -721,7 +2672,7 @@ func (p *Pointer) Release() error {
 }
 
 type KeyboardKeymapEvent struct {
-	Format uint32
+	Format KeyboardKeymapFormat
 	Fd     uintptr
 	Size   uint32
 }
@@ -729,7 +2680,7 @@ type KeyboardKeymapEvent struct {
 type KeyboardEnterEvent struct {
 	Serial  uint32
 	Surface *Surface
-	Keys    []int32
+	Keys    []byte
 }
 
 type KeyboardLeaveEvent struct {
@@ -741,7 +2692,7 @@ type KeyboardKeyEvent struct {
 	Serial uint32
 	Time   uint32
 	Key    uint32
-	State  uint32
+	State  KeyboardKeyState
 }
 
 type KeyboardModifiersEvent struct {
@@ -779,6 +2730,107 @@ func NewKeyboard(c *Connection) *Keyboard {
 	return ret
 }
 
+var keyboardInterface = Interface{
+	Name:    "wl_keyboard",
+	Version: 9,
+	Requests: []Method{
+		{Name: "release", Since: 3, Destructor: true},
+	},
+	Events: []Method{
+		{Name: "keymap", Args: []Arg{{Name: "format", Type: 'u'}, {Name: "fd", Type: 'h'}, {Name: "size", Type: 'u'}}},
+		{Name: "enter", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "surface", Type: 'o', Interface: "wl_surface"}, {Name: "keys", Type: 'a'}}},
+		{Name: "leave", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "surface", Type: 'o', Interface: "wl_surface"}}},
+		{Name: "key", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "time", Type: 'u'}, {Name: "key", Type: 'u'}, {Name: "state", Type: 'u'}}},
+		{Name: "modifiers", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "mods_depressed", Type: 'u'}, {Name: "mods_latched", Type: 'u'}, {Name: "mods_locked", Type: 'u'}, {Name: "group", Type: 'u'}}},
+		{Name: "repeat_info", Since: 4, Args: []Arg{{Name: "rate", Type: 'i'}, {Name: "delay", Type: 'i'}}},
+	},
+}
+
+func (p *Keyboard) Interface() *Interface {
+	return &keyboardInterface
+}
+
+func (p *Keyboard) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev KeyboardKeymapEvent
+		if ev.Format, err = GetEnum[KeyboardKeymapFormat](m); err != nil {
+			return err
+		}
+		if ev.Fd, err = m.GetFD(); err != nil {
+			return err
+		}
+		if ev.Size, err = m.GetUint32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.KeymapChan, "Keyboard.KeymapChan", ev)
+	case 1:
+		var ev KeyboardEnterEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Surface, err = GetObject[*Surface](p.Connection(), m); err != nil {
+			return err
+		}
+		if ev.Keys, err = m.GetArray(); err != nil {
+			return err
+		}
+		return Deliver(p, p.EnterChan, "Keyboard.EnterChan", ev)
+	case 2:
+		var ev KeyboardLeaveEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Surface, err = GetObject[*Surface](p.Connection(), m); err != nil {
+			return err
+		}
+		return Deliver(p, p.LeaveChan, "Keyboard.LeaveChan", ev)
+	case 3:
+		var ev KeyboardKeyEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Key, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.State, err = GetEnum[KeyboardKeyState](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.KeyChan, "Keyboard.KeyChan", ev)
+	case 4:
+		var ev KeyboardModifiersEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.ModsDepressed, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.ModsLatched, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.ModsLocked, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Group, err = m.GetUint32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.ModifiersChan, "Keyboard.ModifiersChan", ev)
+	case 5:
+		var ev KeyboardRepeatInfoEvent
+		if ev.Rate, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.Delay, err = m.GetInt32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.RepeatInfoChan, "Keyboard.RepeatInfoChan", ev)
+	}
+	return nil
+}
+
 func (p *Keyboard) Release() error {
 	return p.Connection().SendRequest(p, 0)
 }
@@ -788,8 +2840,8 @@ type TouchDownEvent struct {
 	Time    uint32
 	Surface *Surface
 	Id      int32
-	X       float32
-	Y       float32
+	X       Fixed
+	Y       Fixed
 }
 
 type TouchUpEvent struct {
@@ -801,8 +2853,8 @@ type TouchUpEvent struct {
 type TouchMotionEvent struct {
 	Time uint32
 	Id   int32
-	X    float32
-	Y    float32
+	X    Fixed
+	Y    Fixed
 }
 
 type TouchFrameEvent struct {
@@ -811,13 +2863,26 @@ type TouchFrameEvent struct {
 type TouchCancelEvent struct {
 }
 
+type TouchShapeEvent struct {
+	Id    int32
+	Major Fixed
+	Minor Fixed
+}
+
+type TouchOrientationEvent struct {
+	Id          int32
+	Orientation Fixed
+}
+
 type Touch struct {
 	BaseProxy
-	DownChan   chan TouchDownEvent
-	UpChan     chan TouchUpEvent
-	MotionChan chan TouchMotionEvent
-	FrameChan  chan TouchFrameEvent
-	CancelChan chan TouchCancelEvent
+	DownChan        chan TouchDownEvent
+	UpChan          chan TouchUpEvent
+	MotionChan      chan TouchMotionEvent
+	FrameChan       chan TouchFrameEvent
+	CancelChan      chan TouchCancelEvent
+	ShapeChan       chan TouchShapeEvent
+	OrientationChan chan TouchOrientationEvent
 }
 
 func NewTouch(c *Connection) *Touch {
@@ -827,10 +2892,114 @@ func NewTouch(c *Connection) *Touch {
 	ret.MotionChan = make(chan TouchMotionEvent, 0)
 	ret.FrameChan = make(chan TouchFrameEvent, 0)
 	ret.CancelChan = make(chan TouchCancelEvent, 0)
+	ret.ShapeChan = make(chan TouchShapeEvent, 0)
+	ret.OrientationChan = make(chan TouchOrientationEvent, 0)
 	c.Register(ret)
 	return ret
 }
 
+var touchInterface = Interface{
+	Name:    "wl_touch",
+	Version: 9,
+	Requests: []Method{
+		{Name: "release", Since: 3, Destructor: true},
+	},
+	Events: []Method{
+		{Name: "down", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "time", Type: 'u'}, {Name: "surface", Type: 'o', Interface: "wl_surface"}, {Name: "id", Type: 'i'}, {Name: "x", Type: 'f'}, {Name: "y", Type: 'f'}}},
+		{Name: "up", Args: []Arg{{Name: "serial", Type: 'u'}, {Name: "time", Type: 'u'}, {Name: "id", Type: 'i'}}},
+		{Name: "motion", Args: []Arg{{Name: "time", Type: 'u'}, {Name: "id", Type: 'i'}, {Name: "x", Type: 'f'}, {Name: "y", Type: 'f'}}},
+		{Name: "frame"},
+		{Name: "cancel"},
+		{Name: "shape", Since: 6, Args: []Arg{{Name: "id", Type: 'i'}, {Name: "major", Type: 'f'}, {Name: "minor", Type: 'f'}}},
+		{Name: "orientation", Since: 6, Args: []Arg{{Name: "id", Type: 'i'}, {Name: "orientation", Type: 'f'}}},
+	},
+}
+
+func (p *Touch) Interface() *Interface {
+	return &touchInterface
+}
+
+func (p *Touch) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev TouchDownEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Surface, err = GetObject[*Surface](p.Connection(), m); err != nil {
+			return err
+		}
+		if ev.Id, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.X, err = m.GetFixed(); err != nil {
+			return err
+		}
+		if ev.Y, err = m.GetFixed(); err != nil {
+			return err
+		}
+		return Deliver(p, p.DownChan, "Touch.DownChan", ev)
+	case 1:
+		var ev TouchUpEvent
+		if ev.Serial, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Id, err = m.GetInt32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.UpChan, "Touch.UpChan", ev)
+	case 2:
+		var ev TouchMotionEvent
+		if ev.Time, err = m.GetUint32(); err != nil {
+			return err
+		}
+		if ev.Id, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.X, err = m.GetFixed(); err != nil {
+			return err
+		}
+		if ev.Y, err = m.GetFixed(); err != nil {
+			return err
+		}
+		return Deliver(p, p.MotionChan, "Touch.MotionChan", ev)
+	case 3:
+		var ev TouchFrameEvent
+		return Deliver(p, p.FrameChan, "Touch.FrameChan", ev)
+	case 4:
+		var ev TouchCancelEvent
+		return Deliver(p, p.CancelChan, "Touch.CancelChan", ev)
+	case 5:
+		var ev TouchShapeEvent
+		if ev.Id, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.Major, err = m.GetFixed(); err != nil {
+			return err
+		}
+		if ev.Minor, err = m.GetFixed(); err != nil {
+			return err
+		}
+		return Deliver(p, p.ShapeChan, "Touch.ShapeChan", ev)
+	case 6:
+		var ev TouchOrientationEvent
+		if ev.Id, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.Orientation, err = m.GetFixed(); err != nil {
+			return err
+		}
+		return Deliver(p, p.OrientationChan, "Touch.OrientationChan", ev)
+	}
+	return nil
+}
+
 func (p *Touch) Release() error {
 	return p.Connection().SendRequest(p, 0)
 }
@@ -840,14 +3009,14 @@ type OutputGeometryEvent struct {
 	Y              int32
 	PhysicalWidth  int32
 	PhysicalHeight int32
-	Subpixel       int32
+	Subpixel       OutputSubpixel
 	Make           string
 	Model          string
-	Transform      int32
+	Transform      OutputTransform
 }
 
 type OutputModeEvent struct {
-	Flags   uint32
+	Flags   OutputMode
 	Width   int32
 	Height  int32
 	Refresh int32
@@ -860,12 +3029,22 @@ type OutputScaleEvent struct {
 	Factor int32
 }
 
+type OutputNameEvent struct {
+	Name string
+}
+
+type OutputDescriptionEvent struct {
+	Description string
+}
+
 type Output struct {
 	BaseProxy
-	GeometryChan chan OutputGeometryEvent
-	ModeChan     chan OutputModeEvent
-	DoneChan     chan OutputDoneEvent
-	ScaleChan    chan OutputScaleEvent
+	GeometryChan    chan OutputGeometryEvent
+	ModeChan        chan OutputModeEvent
+	DoneChan        chan OutputDoneEvent
+	ScaleChan       chan OutputScaleEvent
+	NameChan        chan OutputNameEvent
+	DescriptionChan chan OutputDescriptionEvent
 }
 
 func NewOutput(c *Connection) *Output {
@@ -874,10 +3053,105 @@ func NewOutput(c *Connection) *Output {
 	ret.ModeChan = make(chan OutputModeEvent, 0)
 	ret.DoneChan = make(chan OutputDoneEvent, 0)
 	ret.ScaleChan = make(chan OutputScaleEvent, 0)
+	ret.NameChan = make(chan OutputNameEvent, 0)
+	ret.DescriptionChan = make(chan OutputDescriptionEvent, 0)
 	c.Register(ret)
 	return ret
 }
 
+var outputInterface = Interface{
+	Name:    "wl_output",
+	Version: 4,
+	Requests: []Method{
+		{Name: "release", Since: 3, Destructor: true},
+	},
+	Events: []Method{
+		{Name: "geometry", Args: []Arg{{Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}, {Name: "physical_width", Type: 'i'}, {Name: "physical_height", Type: 'i'}, {Name: "subpixel", Type: 'i'}, {Name: "make", Type: 's'}, {Name: "model", Type: 's'}, {Name: "transform", Type: 'i'}}},
+		{Name: "mode", Args: []Arg{{Name: "flags", Type: 'u'}, {Name: "width", Type: 'i'}, {Name: "height", Type: 'i'}, {Name: "refresh", Type: 'i'}}},
+		{Name: "done", Since: 2},
+		{Name: "scale", Since: 2, Args: []Arg{{Name: "factor", Type: 'i'}}},
+		{Name: "name", Since: 4, Args: []Arg{{Name: "name", Type: 's'}}},
+		{Name: "description", Since: 4, Args: []Arg{{Name: "description", Type: 's'}}},
+	},
+}
+
+func (p *Output) Interface() *Interface {
+	return &outputInterface
+}
+
+func (p *Output) Dispatch(opcode uint32, m *Message) (err error) {
+	switch opcode {
+	case 0:
+		var ev OutputGeometryEvent
+		if ev.X, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.Y, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.PhysicalWidth, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.PhysicalHeight, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.Subpixel, err = GetEnum[OutputSubpixel](m); err != nil {
+			return err
+		}
+		if ev.Make, err = m.GetString(); err != nil {
+			return err
+		}
+		if ev.Model, err = m.GetString(); err != nil {
+			return err
+		}
+		if ev.Transform, err = GetEnum[OutputTransform](m); err != nil {
+			return err
+		}
+		return Deliver(p, p.GeometryChan, "Output.GeometryChan", ev)
+	case 1:
+		var ev OutputModeEvent
+		if ev.Flags, err = GetEnum[OutputMode](m); err != nil {
+			return err
+		}
+		if ev.Width, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.Height, err = m.GetInt32(); err != nil {
+			return err
+		}
+		if ev.Refresh, err = m.GetInt32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.ModeChan, "Output.ModeChan", ev)
+	case 2:
+		var ev OutputDoneEvent
+		return Deliver(p, p.DoneChan, "Output.DoneChan", ev)
+	case 3:
+		var ev OutputScaleEvent
+		if ev.Factor, err = m.GetInt32(); err != nil {
+			return err
+		}
+		return Deliver(p, p.ScaleChan, "Output.ScaleChan", ev)
+	case 4:
+		var ev OutputNameEvent
+		if ev.Name, err = m.GetString(); err != nil {
+			return err
+		}
+		return Deliver(p, p.NameChan, "Output.NameChan", ev)
+	case 5:
+		var ev OutputDescriptionEvent
+		if ev.Description, err = m.GetString(); err != nil {
+			return err
+		}
+		return Deliver(p, p.DescriptionChan, "Output.DescriptionChan", ev)
+	}
+	return nil
+}
+
+func (p *Output) Release() error {
+	return p.Connection().SendRequest(p, 0)
+}
+
 type Region struct {
 	BaseProxy
 }
@@ -888,6 +3162,24 @@ func NewRegion(c *Connection) *Region {
 	return ret
 }
 
+var regionInterface = Interface{
+	Name:    "wl_region",
+	Version: 1,
+	Requests: []Method{
+		{Name: "destroy", Destructor: true},
+		{Name: "add", Args: []Arg{{Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}, {Name: "width", Type: 'i'}, {Name: "height", Type: 'i'}}},
+		{Name: "subtract", Args: []Arg{{Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}, {Name: "width", Type: 'i'}, {Name: "height", Type: 'i'}}},
+	},
+}
+
+func (p *Region) Interface() *Interface {
+	return &regionInterface
+}
+
+func (p *Region) Dispatch(opcode uint32, m *Message) error {
+	return nil
+}
+
 func (p *Region) Destroy() error {
 	return p.Connection().SendRequest(p, 0)
 }
@@ -910,13 +3202,34 @@ func NewSubcompositor(c *Connection) *Subcompositor {
 	return ret
 }
 
+var subcompositorInterface = Interface{
+	Name:    "wl_subcompositor",
+	Version: 1,
+	Requests: []Method{
+		{Name: "destroy", Destructor: true},
+		{Name: "get_subsurface", Args: []Arg{{Name: "id", Type: 'n', Interface: "wl_subsurface"}, {Name: "surface", Type: 'o', Interface: "wl_surface"}, {Name: "parent", Type: 'o', Interface: "wl_surface"}}},
+	},
+	Errors: map[uint32]string{
+		uint32(SubcompositorErrorBadSurface): "SubcompositorErrorBadSurface",
+		uint32(SubcompositorErrorBadParent):  "SubcompositorErrorBadParent",
+	},
+}
+
+func (p *Subcompositor) Interface() *Interface {
+	return &subcompositorInterface
+}
+
+func (p *Subcompositor) Dispatch(opcode uint32, m *Message) error {
+	return nil
+}
+
 func (p *Subcompositor) Destroy() error {
 	return p.Connection().SendRequest(p, 0)
 }
 
 func (p *Subcompositor) GetSubsurface(surface *Surface, parent *Surface) (*Subsurface, error) {
 	ret := NewSubsurface(p.Connection())
-	return ret, p.Connection().SendRequest(p, 1, Proxy(ret), surface, parent)
+	return ret, p.Connection().SendRequest(p, 1, Proxy(ret), ObjectArg(surface), ObjectArg(parent))
 }
 
 type Subsurface struct {
@@ -929,6 +3242,30 @@ func NewSubsurface(c *Connection) *Subsurface {
 	return ret
 }
 
+var subsurfaceInterface = Interface{
+	Name:    "wl_subsurface",
+	Version: 1,
+	Requests: []Method{
+		{Name: "destroy", Destructor: true},
+		{Name: "set_position", Args: []Arg{{Name: "x", Type: 'i'}, {Name: "y", Type: 'i'}}},
+		{Name: "place_above", Args: []Arg{{Name: "sibling", Type: 'o', Interface: "wl_surface"}}},
+		{Name: "place_below", Args: []Arg{{Name: "sibling", Type: 'o', Interface: "wl_surface"}}},
+		{Name: "set_sync"},
+		{Name: "set_desync"},
+	},
+	Errors: map[uint32]string{
+		uint32(SubsurfaceErrorBadSurface): "SubsurfaceErrorBadSurface",
+	},
+}
+
+func (p *Subsurface) Interface() *Interface {
+	return &subsurfaceInterface
+}
+
+func (p *Subsurface) Dispatch(opcode uint32, m *Message) error {
+	return nil
+}
+
 func (p *Subsurface) Destroy() error {
 	return p.Connection().SendRequest(p, 0)
 }
@@ -938,11 +3275,11 @@ func (p *Subsurface) SetPosition(x int32, y int32) error {
 }
 
 func (p *Subsurface) PlaceAbove(sibling *Surface) error {
-	return p.Connection().SendRequest(p, 2, sibling)
+	return p.Connection().SendRequest(p, 2, ObjectArg(sibling))
 }
 
 func (p *Subsurface) PlaceBelow(sibling *Surface) error {
-	return p.Connection().SendRequest(p, 3, sibling)
+	return p.Connection().SendRequest(p, 3, ObjectArg(sibling))
 }
 
 func (p *Subsurface) SetSync() error {
@@ -1,9 +1,10 @@
 package wayland
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
-	"syscall"
 	"testing"
 	"time"
 )
@@ -11,9 +12,9 @@ import (
 func TestConnectionDisconnection(t *testing.T) {
 	d, err := ConnectDisplay("")
 	if err != nil {
-		t.Errorf("Failed to connect to wayland server")
+		t.Fatalf("Failed to connect to wayland server")
 	}
-	d.Connection().Close()
+	err = d.Connection().Close()
 	if err != nil {
 		t.Errorf("Disconnecing wayland server failed")
 	}
@@ -22,67 +23,79 @@ func TestConnectionDisconnection(t *testing.T) {
 func TestSync(t *testing.T) {
 	display, err := ConnectDisplay("")
 	if err != nil {
-		t.Errorf("Failed to connect to wayland server")
+		t.Fatalf("Failed to connect to wayland server")
 	}
-	callback, err := display.Sync()
-	if err != nil {
-		t.Errorf("Sync request failed.")
+	defer display.Connection().Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 1000*time.Millisecond)
+	defer cancel()
+	if err = display.Roundtrip(ctx); err != nil {
+		t.Errorf("Sync failed: %s", err)
 	}
-loop:
-	for {
-		select {
-		case dee := <-display.ErrorChan:
-			t.Log("Error: obj: %d, err_code: %d, msg: %s", dee.ObjectId.Id(), dee.Code, dee.Message)
-			t.Fail()
-			break loop
-		case <-callback.DoneChan:
-			break loop
-		case <-time.After(1000 * time.Millisecond):
-			t.Fail()
-			break loop
-		case display.Connection().Dispatch() <- true:
-		}
-	}
-	display.Connection().Close()
 }
 
 func TestGetRegistry(t *testing.T) {
-	display, err := ConnectDisplay("")
+	display, err := ConnectDisplay("", WithQueuePolicy(QueueDropOldest, 256))
 	if err != nil {
-		t.Errorf("Failed to connect to wayland server")
+		t.Fatalf("Failed to connect to wayland server")
 	}
-	var has_global bool = false
+	defer display.Connection().Close()
 	registry, err := display.GetRegistry()
 	if err != nil {
-		t.Errorf("Disconnecing wayland server failed")
+		t.Fatalf("Registry request failed")
 	}
-loop:
-	for {
-		select {
-		case dee := <-display.ErrorChan:
-			t.Logf("Error: obj: %d, err_code: %d, msg: %s", dee.ObjectId.Id(), dee.Code, dee.Message)
-			t.Fail()
-		case ge := <-registry.GlobalChan:
-			t.Logf("Global obj %s %d %d", ge.Ifc, ge.Name, ge.Version)
-			has_global = true
-		case <-time.After(100 * time.Millisecond):
-			break loop
-		case display.Connection().Dispatch() <- true:
+	ctx, cancel := context.WithTimeout(context.Background(), 1000*time.Millisecond)
+	defer cancel()
+	if err = display.Roundtrip(ctx); err != nil {
+		t.Fatalf("Sync failed: %s", err)
+	}
+	select {
+	case ge := <-registry.GlobalChan:
+		t.Logf("Global obj %s %d %d", ge.Ifc, ge.Name, ge.Version)
+	case <-time.After(100 * time.Millisecond):
+		t.Error("No globals announced")
+	}
+}
+
+func TestEnumString(t *testing.T) {
+	tests := []struct {
+		value fmt.Stringer
+		want  string
+	}{
+		{ShmFormatXrgb8888, "xrgb8888"},
+		{ShmFormatNv12, "nv12"},
+		{ShmFormat(7), "ShmFormat(7)"},
+		{OutputTransformFlipped90, "flipped_90"},
+		{SeatCapabilityPointer | SeatCapabilityTouch, "pointer|touch"},
+		{SeatCapability(0), "0"},
+		{SeatCapabilityKeyboard | 0x10, "keyboard|0x10"},
+		{ShellSurfaceResizeNone, "none"},
+		{ShellSurfaceResizeBottomRight, "bottom|right"},
+	}
+	for _, test := range tests {
+		if got := test.value.String(); got != test.want {
+			t.Errorf("Expected %q, got %q", test.want, got)
 		}
 	}
-	display.Connection().Close()
-	if !has_global {
-		t.Fail()
+}
+
+func TestEnumValidation(t *testing.T) {
+	caps := SeatCapabilityPointer | SeatCapabilityKeyboard
+	if !caps.Has(SeatCapabilityKeyboard) || caps.Has(SeatCapabilityTouch) || caps.Has(SeatCapabilityKeyboard|SeatCapabilityTouch) {
+		t.Errorf("Has reports wrong flags for %s", caps)
+	}
+	if !caps.IsValid() || (caps | 0x100).IsValid() {
+		t.Error("Unknown capability bits not detected")
+	}
+	if !ShmFormatYuv420.IsValid() || ShmFormat(7).IsValid() {
+		t.Error("Unknown format not detected")
 	}
 }
 
-func ExamplePaint() {
+func Example_paint() {
 	var (
 		shm        *Shm
 		compositor *Compositor
 		surface    *Surface
-		data       []byte
-		buf        *Buffer
 		shell      *Shell
 		pointer    *Pointer
 		seat       *Seat
@@ -119,64 +132,58 @@ loop:
 		case ev := <-registry.GlobalChan:
 			if ev.Ifc == "wl_shm" {
 				shm = NewShm(display.Connection())
-				err = registry.Bind(ev.Name, ev.Ifc, ev.Version, shm)
+				// supported formats are not interesting here
+				display.Connection().SetQueuePolicy(shm, QueueDropUnsubscribed, 0)
+				err = registry.Bind(ev.Name, ev.Ifc, min(ev.Version, shm.Interface().Version), shm)
 				if err != nil {
 					panic("unable to bind Shm object")
 				}
 			}
 			if ev.Ifc == "wl_compositor" {
 				compositor = NewCompositor(display.Connection())
-				err = registry.Bind(ev.Name, ev.Ifc, ev.Version, compositor)
+				err = registry.Bind(ev.Name, ev.Ifc, min(ev.Version, compositor.Interface().Version), compositor)
 				if err != nil {
 					panic("unable to bind Compositor object")
 				}
 			}
 			if ev.Ifc == "wl_shell" {
 				shell = NewShell(display.Connection())
-				err = registry.Bind(ev.Name, ev.Ifc, ev.Version, shell)
+				err = registry.Bind(ev.Name, ev.Ifc, min(ev.Version, shell.Interface().Version), shell)
 				if err != nil {
 					panic("unable to bind shell object")
 				}
 			}
 			if ev.Ifc == "wl_seat" {
 				seat = NewSeat(display.Connection())
-				err = registry.Bind(ev.Name, ev.Ifc, ev.Version, seat)
+				display.Connection().SetQueuePolicy(seat, QueueDropOldest, 4)
+				err = registry.Bind(ev.Name, ev.Ifc, min(ev.Version, seat.Interface().Version), seat)
 				if err != nil {
 					panic("unable to bind seat object")
 				}
 			}
 		case <-callback.DoneChan:
 			break loop
-		case display.Connection().Dispatch() <- true:
 		}
 	}
 
-	// query seat capabilities
-	callback, err = display.Sync()
+	// seat events are queued, so they can be read after the roundtrip
+	err = display.Roundtrip(context.Background())
 	if err != nil {
 		panic("Sync request failed")
 	}
-loop2:
-	for {
-		select {
-		case <-shm.FormatChan:
-		case <-display.DeleteIdChan:
-		case ev := <-seat.CapabilitiesChan:
-			if (ev.Capabilities & SeatCapabilityPointer) != 0 {
-				pointer, err = seat.GetPointer()
-				if err != nil {
-					panic("unable to get pointer object")
-				}
-				keyboard, err = seat.GetKeyboard()
-				if err != nil {
-					panic("unable to get keyboard object")
-				}
-			}
-		case <-seat.NameChan:
-		case <-callback.DoneChan:
-			break loop2
-		case display.Connection().Dispatch() <- true:
+	ev := <-seat.CapabilitiesChan
+	if (ev.Capabilities & SeatCapabilityPointer) != 0 {
+		pointer, err = seat.GetPointer()
+		if err != nil {
+			panic("unable to get pointer object")
 		}
+		// newer seats add frame and axis events nobody reads here
+		display.Connection().SetQueuePolicy(pointer, QueueDropOldest, 32)
+		keyboard, err = seat.GetKeyboard()
+		if err != nil {
+			panic("unable to get keyboard object")
+		}
+		display.Connection().SetQueuePolicy(keyboard, QueueDropOldest, 32)
 	}
 	// if we don't have a pointer - just exit program
 	if pointer == nil {
@@ -187,61 +194,66 @@ loop2:
 		panic("Surface creation failed")
 	}
 
-	// create shm buffer
-	file, err := CreateAnonymousFile(int(size))
-	if err != nil {
-		panic("Unable to create file")
-	}
-	data, err = syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
-	if err != nil {
-		panic("Unable to mmap file")
-	}
+	// the picture is painted here and copied to a free shm buffer for
+	// every frame, the compositor may still read the previous one. The
+	// loop below reads input, so it must not wait in BufferPool.Get;
+	// frames without a free buffer are drawn once one is released.
+	data := make([]byte, size)
 	for i, _ := range data {
 		data[i] = 255
 	}
-	pool, err := shm.CreatePool(file.Fd(), size)
-	if err != nil {
-		panic(fmt.Sprintf("Unable to create pool: %s", err))
-	}
-	buf, err = pool.CreateBuffer(0, width, height, stride, ShmFormatArgb8888)
-	if err != nil {
-		panic("Unable to create buffer")
+	pool := NewBufferPool(shm, 2)
+	defer pool.Close()
+	pending := false
+	frame := func(x, y, w, h int32) {
+		buf, err := pool.TryGet(width, height, stride, ShmFormatArgb8888)
+		if err != nil {
+			panic(fmt.Sprintf("Unable to get buffer: %s", err))
+		}
+		if buf == nil {
+			pending = true
+			return
+		}
+		if pending {
+			x, y, w, h = 0, 0, width, height
+			pending = false
+		}
+		copy(buf.Data, data)
+		if err = surface.Attach(buf.Buffer, 0, 0); err != nil {
+			panic("Unable to attach buffer")
+		}
+		if err = surface.Damage(x, y, w, h); err != nil {
+			panic("Unable to damage surface")
+		}
+		if err = surface.Commit(); err != nil {
+			panic("Unable to commit surface")
+		}
 	}
-	pool.Destroy()
 	shsurf, err := shell.GetShellSurface(surface)
 	if err != nil {
 		panic("Unable to shell surface")
 	}
 	shsurf.SetToplevel()
-	err = surface.Attach(buf, width, height)
-	if err != nil {
-		panic("Unable to attach buffer")
-	}
-	err = surface.Damage(0, 0, width, height)
-	if err != nil {
-		panic("Unable to damage surface")
-	}
-	surface.Commit()
-	if err != nil {
-		panic("Unable to commit surface")
-	}
+	frame(0, 0, width, height)
 
 	// main application loop
 main_loop:
 	for {
 		select {
-		case <-display.DeleteIdChan:
+		case <-pool.Released():
+			if pending {
+				frame(0, 0, width, height)
+			}
 		case <-seat.NameChan:
 		case <-seat.CapabilitiesChan:
-		case <-buf.ReleaseChan:
 		case <-pointer.EnterChan:
 		case <-pointer.LeaveChan:
 		case ev := <-shsurf.PingChan:
 			shsurf.Pong(ev.Serial)
 		case ev := <-pointer.MotionChan:
 			if pressed {
-				x1 := int32(ev.SurfaceX)
-				y1 := int32(ev.SurfaceY)
+				x1 := int32(ev.SurfaceX.Int())
+				y1 := int32(ev.SurfaceY.Int())
 				offsets := []int32{0, 1, -1, 2, -2}
 				for _, x := range offsets {
 					for _, y := range offsets {
@@ -254,11 +266,7 @@ main_loop:
 						data[(dx)*stride+(dy)*4+2] = 0
 					}
 				}
-				if surface != nil {
-					surface.Attach(buf, 0, 0)
-					surface.Damage(x1-1, y1-1, 4, 4)
-					surface.Commit()
-				}
+				frame(x1-2, y1-2, 5, 5)
 			}
 		case ev := <-pointer.ButtonChan:
 			log.Println("PointerButton: ", ev.Time, ev.Button, ev.State)
@@ -280,10 +288,43 @@ main_loop:
 				fmt.Println("OK")
 				break main_loop
 			}
-		case display.Connection().Dispatch() <- true:
 		}
 	}
 	// Output:
 	// OK
 	display.Connection().Close()
 }
+
+// benchmarkFrame submits a frame with several damaged regions per op to a
+// server draining the socket and reports the number of sendmsg calls.
+func benchmarkFrame(b *testing.B, opts ...Option) {
+	display, server := newTestDisplay(b, opts...)
+	defer display.Connection().Close()
+	go io.Copy(io.Discard, server)
+	ctx := display.Connection()
+	surface := NewSurface(ctx)
+	ctx.register(surface)
+	buffer := NewBuffer(ctx)
+	ctx.register(buffer)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		surface.Attach(buffer, 0, 0)
+		for d := int32(0); d < 8; d++ {
+			surface.Damage(d*10, d*10, 10, 10)
+		}
+		surface.Commit()
+		ctx.Flush()
+	}
+	b.StopTimer()
+	ctx.wmu.Lock()
+	b.ReportMetric(float64(ctx.writes)/float64(b.N), "sendmsg/op")
+	ctx.wmu.Unlock()
+}
+
+func BenchmarkFrameUnbatched(b *testing.B) {
+	benchmarkFrame(b)
+}
+
+func BenchmarkFrameBatched(b *testing.B) {
+	benchmarkFrame(b, WithBatching())
+}
@@ -0,0 +1,91 @@
+package wayland
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// outSize is the amount of request data collected before it is flushed.
+const outSize = 4 * maxMessageSize
+
+// idleFlushDelay is how long batched requests wait for more requests
+// before the dispatcher sends them on its own.
+const idleFlushDelay = time.Millisecond
+
+// WithBatching keeps requests in the connection's output buffer until
+// Flush is called, the buffer fills up or the connection waits for events
+// in Roundtrip, Callback.Wait or manual dispatch. In automatic dispatch
+// mode the dispatcher also sends them when no more requests were queued
+// for idleFlushDelay, so applications which only read event channels do
+// not stall. Without it every request is sent immediately.
+func WithBatching() Option {
+	return func(c *Connection) {
+		c.batching = true
+	}
+}
+
+// queueRequestLocked appends a marshalled request to the output buffer.
+// Must be called with wmu held.
+func (context *Connection) queueRequestLocked(msg *Message) error {
+	if msg.data.Len()+8 > maxMessageSize {
+		return errors.New("Request exceeds maximum message size.")
+	}
+	if len(msg.sendFds) > maxFds {
+		return errors.New("Request carries too many file descriptors.")
+	}
+	if context.out.Len()+msg.data.Len()+8 > outSize || len(context.outFds)+len(msg.sendFds) > maxFds {
+		if err := context.flushLocked(); err != nil {
+			return err
+		}
+	}
+	pending := context.out.Len() > 0
+	msg.marshal(&context.out)
+	context.outFds = append(context.outFds, msg.sendFds...)
+	if !context.batching {
+		return context.flushLocked()
+	}
+	if !pending && !context.manual {
+		// wake up the dispatcher to flush once the application is idle
+		select {
+		case context.flushRequest <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush sends all buffered requests.
+func (context *Connection) Flush() error {
+	if err := context.Err(); err != nil {
+		return err
+	}
+	context.wmu.Lock()
+	defer context.wmu.Unlock()
+	return context.flushLocked()
+}
+
+// flushLocked writes the output buffer with as few sendmsg calls as
+// possible. All collected fds travel with the first one. A write error
+// terminates the connection. Must be called with wmu held.
+func (context *Connection) flushLocked() error {
+	var control []byte
+	if len(context.outFds) > 0 {
+		control = syscall.UnixRights(context.outFds...)
+	}
+	for context.out.Len() > 0 {
+		n, _, err := context.conn.WriteMsgUnix(context.out.Bytes(), control, nil)
+		context.out.Next(n)
+		if n > 0 || err == nil {
+			control = nil
+			context.outFds = context.outFds[:0]
+			context.writes++
+		}
+		if err != nil {
+			context.out.Reset()
+			context.fail(err)
+			return err
+		}
+	}
+	return nil
+}
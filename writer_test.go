@@ -0,0 +1,143 @@
+package wayland
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBatchingWaitsForFlush(t *testing.T) {
+	// without manual dispatch the dispatcher flushes when idle
+	display, server := newTestDisplay(t, WithBatching(), WithManualDispatch())
+	defer display.Connection().Close()
+	region := NewRegion(display.Connection())
+	display.Connection().register(region)
+	for i := int32(0); i < 3; i++ {
+		if err := region.Add(i, i, 10, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := ReadWaylandMessage(server); err == nil {
+		t.Fatal("Request sent before Flush")
+	}
+	server.SetReadDeadline(time.Time{})
+
+	if err := display.Connection().Flush(); err != nil {
+		t.Fatal(err)
+	}
+	r := newMessageReader(server, &fdQueue{})
+	for i := int32(0); i < 3; i++ {
+		msg, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x, _ := msg.GetInt32(); msg.Id != region.Id() || msg.Opcode != 1 || x != i {
+			t.Errorf("Unexpected request %d/%d x=%d", msg.Id, msg.Opcode, x)
+		}
+	}
+	if w := display.Connection().writes; w != 1 {
+		t.Errorf("Expected a single sendmsg, got %d", w)
+	}
+}
+
+func TestBatchingSplitsFds(t *testing.T) {
+	const pools = maxFds + 2
+	display, server := newTestDisplay(t, WithBatching(), WithManualDispatch())
+	defer display.Connection().Close()
+	shm := NewShm(display.Connection())
+	display.Connection().register(shm)
+	files := tempFiles(t, pools)
+	for _, f := range files {
+		if _, err := shm.CreatePool(f.Fd(), 4096); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := display.Connection().Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if w := display.Connection().writes; w != 2 {
+		t.Errorf("Expected 2 sendmsg calls, got %d", w)
+	}
+	r := newMessageReader(server, &fdQueue{})
+	for i, f := range files {
+		msg, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.GetUint32()
+		fd, err := msg.GetFD()
+		if err != nil {
+			t.Fatalf("Pool %d: %s", i, err)
+		}
+		if !sameFile(t, fd, f) {
+			t.Errorf("Pool %d received the wrong fd", i)
+		}
+	}
+}
+
+func TestRequestLimits(t *testing.T) {
+	display, _ := newTestDisplay(t)
+	defer display.Connection().Close()
+	source := NewDataSource(display.Connection())
+	display.Connection().register(source)
+	long := make([]byte, maxMessageSize)
+	for i := range long {
+		long[i] = 'a'
+	}
+	if err := source.Offer(string(long)); err == nil {
+		t.Error("Oversized request accepted")
+	}
+	fds := make([]interface{}, maxFds+1)
+	for i := range fds {
+		fds[i] = uintptr(0)
+	}
+	if err := display.Connection().SendRequest(source, 0, fds...); err == nil {
+		t.Error("Request with too many fds accepted")
+	}
+	if err := display.Connection().Err(); err != nil {
+		t.Errorf("Rejected request terminated the connection: %s", err)
+	}
+}
+
+func TestBatchingFlushesWhenIdle(t *testing.T) {
+	display, server := newTestDisplay(t, WithBatching())
+	defer display.Connection().Close()
+	region := NewRegion(display.Connection())
+	display.Connection().register(region)
+	for i := int32(0); i < 3; i++ {
+		if err := region.Add(i, i, 10, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the application only waits for events now
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	r := newMessageReader(server, &fdQueue{})
+	for i := int32(0); i < 3; i++ {
+		if _, err := r.Next(); err != nil {
+			t.Fatalf("Batched request %d not flushed: %s", i, err)
+		}
+	}
+	display.Connection().wmu.Lock()
+	defer display.Connection().wmu.Unlock()
+	if w := display.Connection().writes; w != 1 {
+		t.Errorf("Expected a single sendmsg, got %d", w)
+	}
+}
+
+func TestRoundtripFlushes(t *testing.T) {
+	display, server := newTestDisplay(t, WithBatching(), WithManualDispatch())
+	defer display.Connection().Close()
+	go serveSync(server)
+	if err := display.Roundtrip(testContext(t)); err != nil {
+		t.Errorf("Roundtrip failed: %s", err)
+	}
+}
+
+func TestFlushAfterClose(t *testing.T) {
+	display, _ := newTestDisplay(t, WithBatching())
+	display.Connection().Close()
+	if err := display.Connection().Flush(); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("Expected ErrConnectionClosed, got %v", err)
+	}
+}